@@ -0,0 +1,417 @@
+package seq
+
+// The Deque is a 2-3 finger tree, as described by Hinze and Paterson. Each
+// level of the tree keeps between one and four items at either end (its
+// digits), with everything in between held by a deeper level whose items are
+// nodes of two or three items of the level above. Items at the top level are
+// the Deque's elements themselves. Every node and level caches its size, so
+// that the tree can be split at an index.
+//
+// The depth of a level says what its items are: at depth 0 they're elements,
+// at every depth below they're *ftNodes.
+
+// A node of two or three items from the level above it
+type ftNode struct {
+	items []interface{}
+	size  uint64
+}
+
+// A single level of a finger tree. A nil *fingerTree is empty, and a tree with
+// no back holds just the one item in front.
+type fingerTree struct {
+	front []interface{}
+	mid   *fingerTree
+	back  []interface{}
+	size  uint64
+}
+
+// Returns the number of elements held by an item at the given depth
+func ftItemSize(item interface{}, depth uint) uint64 {
+	if depth == 0 {
+		return 1
+	}
+	return item.(*ftNode).size
+}
+
+func ftDigitSize(items []interface{}, depth uint) uint64 {
+	var size uint64
+	for _, item := range items {
+		size += ftItemSize(item, depth)
+	}
+	return size
+}
+
+func newFTNode(depth uint, items ...interface{}) *ftNode {
+	return &ftNode{items, ftDigitSize(items, depth)}
+}
+
+// Returns a tree with the given digits and middle, neither digit being empty
+func newFingerTree(front []interface{}, mid *fingerTree, back []interface{}, depth uint) *fingerTree {
+	size := ftDigitSize(front, depth) + mid.sizeOf() + ftDigitSize(back, depth)
+	return &fingerTree{front, mid, back, size}
+}
+
+func (t *fingerTree) sizeOf() uint64 {
+	if t == nil {
+		return 0
+	}
+	return t.size
+}
+
+// Returns a new slice of the given items followed by those in the slice
+func ftPrepend(items []interface{}, s ...interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(s)+len(items)), s...), items...)
+}
+
+// Returns a new slice of the items in the slice followed by the given ones
+func ftAppend(items []interface{}, s ...interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(s)+len(items)), items...), s...)
+}
+
+func (t *fingerTree) pushFront(item interface{}, depth uint) *fingerTree {
+	if t == nil {
+		return &fingerTree{front: []interface{}{item}, size: ftItemSize(item, depth)}
+	} else if t.back == nil {
+		return newFingerTree([]interface{}{item}, nil, t.front, depth)
+	} else if len(t.front) < 4 {
+		return newFingerTree(ftPrepend(t.front, item), t.mid, t.back, depth)
+	}
+	n := newFTNode(depth, t.front[1], t.front[2], t.front[3])
+	mid := t.mid.pushFront(n, depth+1)
+	return newFingerTree([]interface{}{item, t.front[0]}, mid, t.back, depth)
+}
+
+func (t *fingerTree) pushBack(item interface{}, depth uint) *fingerTree {
+	if t == nil {
+		return t.pushFront(item, depth)
+	} else if t.back == nil {
+		return newFingerTree(t.front, nil, []interface{}{item}, depth)
+	} else if len(t.back) < 4 {
+		return newFingerTree(t.front, t.mid, ftAppend(t.back, item), depth)
+	}
+	n := newFTNode(depth, t.back[0], t.back[1], t.back[2])
+	mid := t.mid.pushBack(n, depth+1)
+	return newFingerTree(t.front, mid, []interface{}{t.back[3], item}, depth)
+}
+
+// Returns a tree holding the given items, which may be empty
+func ftFromDigit(items []interface{}, depth uint) *fingerTree {
+	var t *fingerTree
+	for _, item := range items {
+		t = t.pushBack(item, depth)
+	}
+	return t
+}
+
+// Like newFingerTree, but front may be empty, in which case the first node of
+// mid is taken apart to make it
+func ftDeepFront(front []interface{}, mid *fingerTree, back []interface{}, depth uint) *fingerTree {
+	if len(front) > 0 {
+		return newFingerTree(front, mid, back, depth)
+	} else if mid == nil {
+		return ftFromDigit(back, depth)
+	}
+	n, rest := mid.popFront(depth + 1)
+	return newFingerTree(n.(*ftNode).items, rest, back, depth)
+}
+
+// Like newFingerTree, but back may be empty, in which case the last node of
+// mid is taken apart to make it
+func ftDeepBack(front []interface{}, mid *fingerTree, back []interface{}, depth uint) *fingerTree {
+	if len(back) > 0 {
+		return newFingerTree(front, mid, back, depth)
+	} else if mid == nil {
+		return ftFromDigit(front, depth)
+	}
+	n, rest := mid.popBack(depth + 1)
+	return newFingerTree(front, rest, n.(*ftNode).items, depth)
+}
+
+// Returns the first item in the tree, which must not be empty, and the tree
+// without it
+func (t *fingerTree) popFront(depth uint) (interface{}, *fingerTree) {
+	if t.back == nil {
+		return t.front[0], nil
+	}
+	return t.front[0], ftDeepFront(t.front[1:], t.mid, t.back, depth)
+}
+
+// Returns the last item in the tree, which must not be empty, and the tree
+// without it
+func (t *fingerTree) popBack(depth uint) (interface{}, *fingerTree) {
+	if t.back == nil {
+		return t.front[0], nil
+	}
+	last := len(t.back) - 1
+	return t.back[last], ftDeepBack(t.front, t.mid, t.back[:last], depth)
+}
+
+// Groups between 2 and 12 items into nodes of two or three
+func ftNodes(items []interface{}, depth uint) []interface{} {
+	var nodes []interface{}
+	for len(items) > 4 {
+		nodes = append(nodes, newFTNode(depth, items[:3]...))
+		items = items[3:]
+	}
+	if len(items) == 4 {
+		return append(nodes, newFTNode(depth, items[:2]...), newFTNode(depth, items[2:]...))
+	}
+	return append(nodes, newFTNode(depth, items...))
+}
+
+// Returns a tree of the items in t, followed by the given items, followed by
+// the items in t2
+func ftConcat(t *fingerTree, items []interface{}, t2 *fingerTree, depth uint) *fingerTree {
+	switch {
+	case t == nil:
+		for i := len(items) - 1; i >= 0; i-- {
+			t2 = t2.pushFront(items[i], depth)
+		}
+		return t2
+	case t2 == nil:
+		for _, item := range items {
+			t = t.pushBack(item, depth)
+		}
+		return t
+	case t.back == nil:
+		return ftConcat(nil, items, t2, depth).pushFront(t.front[0], depth)
+	case t2.back == nil:
+		return ftConcat(t, items, nil, depth).pushBack(t2.front[0], depth)
+	}
+
+	inner := make([]interface{}, 0, len(t.back)+len(items)+len(t2.front))
+	inner = append(append(append(inner, t.back...), items...), t2.front...)
+	mid := ftConcat(t.mid, ftNodes(inner, depth), t2.mid, depth+1)
+	return newFingerTree(t.front, mid, t2.back, depth)
+}
+
+// Splits a digit around the item holding the element at index i, which must
+// be within it
+func ftSplitDigit(items []interface{}, i uint64, depth uint) ([]interface{}, interface{}, []interface{}) {
+	for j, item := range items {
+		size := ftItemSize(item, depth)
+		if i < size {
+			return items[:j], item, items[j+1:]
+		}
+		i -= size
+	}
+	panic("index out of range of finger tree digit")
+}
+
+// Splits the tree around the item holding the element at index i, which must be
+// less than the tree's size. Returns the tree of items before that one, the
+// item, and the tree of items after it.
+func (t *fingerTree) split(i uint64, depth uint) (*fingerTree, interface{}, *fingerTree) {
+	if t.back == nil {
+		return nil, t.front[0], nil
+	}
+
+	frontSize := ftDigitSize(t.front, depth)
+	if i < frontSize {
+		l, item, r := ftSplitDigit(t.front, i, depth)
+		return ftFromDigit(l, depth), item, ftDeepFront(r, t.mid, t.back, depth)
+	}
+	i -= frontSize
+
+	if i < t.mid.sizeOf() {
+		ml, n, mr := t.mid.split(i, depth+1)
+		l, item, r := ftSplitDigit(n.(*ftNode).items, i-ml.sizeOf(), depth)
+		return ftDeepBack(t.front, ml, l, depth), item, ftDeepFront(r, mr, t.back, depth)
+	}
+
+	l, item, r := ftSplitDigit(t.back, i-t.mid.sizeOf(), depth)
+	return ftDeepBack(t.front, t.mid, l, depth), item, ftFromDigit(r, depth)
+}
+
+// Calls fn on each element held by the item, in order, until fn returns false.
+// Returns false if fn did.
+func ftEachItem(item interface{}, depth uint, fn func(interface{}) bool) bool {
+	if depth == 0 {
+		return fn(item)
+	}
+	for _, kid := range item.(*ftNode).items {
+		if !ftEachItem(kid, depth-1, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Calls fn on each element in the tree, in order, until fn returns false.
+// Returns false if fn did.
+func (t *fingerTree) each(depth uint, fn func(interface{}) bool) bool {
+	if t == nil {
+		return true
+	}
+	for _, item := range t.front {
+		if !ftEachItem(item, depth, fn) {
+			return false
+		}
+	}
+	if !t.mid.each(depth+1, fn) {
+		return false
+	}
+	for _, item := range t.back {
+		if !ftEachItem(item, depth, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Deque is an implementation of Seq in the form of a persistent double-ended
+// queue. Elements can be pushed onto and popped off of either end in amortized
+// O(1) time, and two Deques can be concatenated, or one split in two at an
+// index, in O(log(N)) time. It's built on a finger tree, so like the other
+// types every operation shares nodes with the Deque it was performed on rather
+// than copying it.
+//
+// A nil *Deque is an empty Deque, and all operations which leave a Deque empty
+// return nil. A zero Deque, which is what UnmarshalJSON makes from an empty JSON
+// array, is empty too.
+type Deque struct {
+	root *fingerTree
+}
+
+// Returns a Deque with the given root, or nil if it's empty
+func newDeque(root *fingerTree) *Deque {
+	if root == nil {
+		return nil
+	}
+	return &Deque{root}
+}
+
+// NewDeque returns a new Deque comprised of the given elements (or no elements,
+// for an empty deque), with the first element at the front
+func NewDeque(els ...interface{}) *Deque {
+	var t *fingerTree
+	for i := range els {
+		t = t.pushBack(els[i], 0)
+	}
+	return newDeque(t)
+}
+
+func (d *Deque) tree() *fingerTree {
+	if d == nil {
+		return nil
+	}
+	return d.root
+}
+
+// Size returns the number of elements in the Deque. Completes in O(1) time.
+func (d *Deque) Size() uint64 {
+	return d.tree().sizeOf()
+}
+
+// PushFront returns a copy of the Deque with the given element added to the
+// front of it. Completes in amortized O(1) time.
+func (d *Deque) PushFront(el interface{}) *Deque {
+	return &Deque{d.tree().pushFront(el, 0)}
+}
+
+// PushBack returns a copy of the Deque with the given element added to the back
+// of it. Completes in amortized O(1) time.
+func (d *Deque) PushBack(el interface{}) *Deque {
+	return &Deque{d.tree().pushBack(el, 0)}
+}
+
+// PopFront returns the element at the front of the Deque, a copy of the Deque
+// with that element removed, and true. If the Deque is empty returns nil, the
+// empty Deque, and false. Completes in amortized O(1) time.
+func (d *Deque) PopFront() (interface{}, *Deque, bool) {
+	if d.tree() == nil {
+		return nil, d, false
+	}
+	el, root := d.root.popFront(0)
+	return el, newDeque(root), true
+}
+
+// PopBack returns the element at the back of the Deque, a copy of the Deque
+// with that element removed, and true. If the Deque is empty returns nil, the
+// empty Deque, and false. Completes in amortized O(1) time.
+func (d *Deque) PopBack() (interface{}, *Deque, bool) {
+	if d.tree() == nil {
+		return nil, d, false
+	}
+	el, root := d.root.popBack(0)
+	return el, newDeque(root), true
+}
+
+// Concat returns a Deque of the elements of this Deque followed by those of the
+// given one. Completes in O(log(min(N, M))) time.
+func (d *Deque) Concat(d2 *Deque) *Deque {
+	return newDeque(ftConcat(d.tree(), nil, d2.tree(), 0))
+}
+
+// SplitAt returns two Deques, the first holding the elements before index i and
+// the second holding the elements from index i on. If i is greater than the
+// Deque's size the first Deque holds all of the elements. Completes in
+// O(log(min(i, N-i))) time.
+func (d *Deque) SplitAt(i uint64) (*Deque, *Deque) {
+	if i == 0 {
+		return nil, d
+	} else if i >= d.Size() {
+		return d, nil
+	}
+	l, el, r := d.root.split(i, 0)
+	return newDeque(l), &Deque{r.pushFront(el, 0)}
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. It is the same
+// as PopFront.
+func (d *Deque) FirstRest() (interface{}, Seq, bool) {
+	el, nd, ok := d.PopFront()
+	return el, nd, ok
+}
+
+// Calls fn on each element of the Deque, front to back, until fn returns false
+func (d *Deque) each(fn func(interface{}) bool) {
+	d.tree().each(0, fn)
+}
+
+// Hash implements the Hash method for the Setable interface
+func (d *Deque) Hash(i uint32) uint32 {
+	sum := uint32(0)
+	d.each(func(el interface{}) bool {
+		sum += hash(el, i)
+		return true
+	})
+	return sum
+}
+
+// Equal implements Equal for the Setable and Comparable interfaces
+func (d *Deque) Equal(v interface{}) bool {
+	d2, ok := v.(*Deque)
+	if !ok || d.Size() != d2.Size() {
+		return false
+	}
+
+	eq := true
+	d.each(func(el interface{}) bool {
+		var el2 interface{}
+		el2, d2, _ = d2.PopFront()
+		eq = equal(el, el2)
+		return eq
+	})
+	return eq
+}
+
+// String is an implementation of String for Stringer interface.
+func (d *Deque) String() string {
+	return ToString(d, "<(", ")>")
+}
+
+// ToDeque returns the elements in the Seq as a Deque, with the Seq's first
+// element at the front. Has similar properties as ToSlice. In general this
+// completes in O(N) time. If the given Seq is already a Deque it will complete
+// in O(1) time.
+func ToDeque(s Seq) *Deque {
+	if d, ok := s.(*Deque); ok {
+		return d
+	}
+	var t *fingerTree
+	for el := range Values(s) {
+		t = t.pushBack(el, 0)
+	}
+	return newDeque(t)
+}
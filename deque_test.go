@@ -0,0 +1,186 @@
+package seq
+
+import (
+	"math/rand"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Asserts that the Deque is a properly formed finger tree holding the given
+// elements in order
+func assertDeque(t *T, ints []interface{}, d *Deque) {
+	var walkItem func(item interface{}, depth uint) uint64
+	walkItem = func(item interface{}, depth uint) uint64 {
+		if depth == 0 {
+			return 1
+		}
+		n := item.(*ftNode)
+		assert.True(t, len(n.items) == 2 || len(n.items) == 3)
+		var size uint64
+		for _, kid := range n.items {
+			size += walkItem(kid, depth-1)
+		}
+		assert.Equal(t, size, n.size)
+		return size
+	}
+	var walk func(ft *fingerTree, depth uint) uint64
+	walk = func(ft *fingerTree, depth uint) uint64 {
+		if ft == nil {
+			return 0
+		} else if ft.back == nil {
+			assert.Equal(t, 1, len(ft.front))
+			assert.Nil(t, ft.mid)
+		}
+		assert.True(t, len(ft.front) >= 1 && len(ft.front) <= 4)
+		assert.True(t, len(ft.back) <= 4)
+		size := walk(ft.mid, depth+1)
+		for _, item := range append(ft.front[:len(ft.front):len(ft.front)], ft.back...) {
+			size += walkItem(item, depth)
+		}
+		assert.Equal(t, size, ft.size)
+		return size
+	}
+
+	if d != nil {
+		assert.NotNil(t, d.root)
+	}
+	assert.Equal(t, uint64(len(ints)), walk(d.tree(), 0))
+	assert.Equal(t, uint64(len(ints)), d.Size())
+	assert.Equal(t, uint64(len(ints)), Size(d))
+	assertSeqSlice(t, ints, d)
+}
+
+// Test creating a Deque and calling the Seq interface methods on it
+func TestDequeSeq(t *T) {
+	ints := []interface{}{1, "a", 5.0}
+
+	d := NewDeque(ints...)
+	sd := testSeqGen(t, d, ints)
+
+	// sd should be empty at this point
+	d = ToDeque(sd)
+	var nilpointer *Deque
+	assert.Equal(t, uint64(0), Size(d))
+	assert.Equal(t, nilpointer, d)
+	assert.Equal(t, nilpointer, NewDeque())
+	assert.Equal(t, "<( 1 a 5 )>", NewDeque(ints...).String())
+}
+
+// Test pushing and popping at both ends of a Deque, and that older versions of
+// it are never changed
+func TestDequePushPop(t *T) {
+	r := rand.New(rand.NewSource(1))
+	var d *Deque
+	var ints []interface{}
+	deques, intss := []*Deque{d}, [][]interface{}{ints}
+	for i := 0; i < 2000; i++ {
+		switch r.Intn(5) {
+		case 0:
+			d, ints = d.PushFront(i), append([]interface{}{i}, ints...)
+		case 1:
+			d, ints = d.PushBack(i), append(ints[:len(ints):len(ints)], i)
+		case 2:
+			el, nd, ok := d.PopFront()
+			assert.Equal(t, len(ints) > 0, ok)
+			if ok {
+				assert.Equal(t, ints[0], el)
+				ints = ints[1:]
+			}
+			d = nd
+		case 3:
+			el, nd, ok := d.PopBack()
+			assert.Equal(t, len(ints) > 0, ok)
+			if ok {
+				assert.Equal(t, ints[len(ints)-1], el)
+				ints = ints[:len(ints)-1]
+			}
+			d = nd
+		default:
+			// Pushing more often than popping lets the Deque grow deeper
+			d, ints = d.PushBack(i), append(ints[:len(ints):len(ints)], i)
+		}
+		deques, intss = append(deques, d), append(intss, ints)
+	}
+
+	for i := range deques {
+		assertDeque(t, intss[i], deques[i])
+	}
+}
+
+// Test concatenating Deques of many sizes, and splitting the results back up
+// at every index
+func TestDequeConcatSplit(t *T) {
+	sizes := []int{0, 1, 2, 3, 5, 8, 13, 40, 100}
+	for _, n1 := range sizes {
+		for _, n2 := range sizes {
+			ints := intsTo(n1 + n2)
+			d1, d2 := NewDeque(ints[:n1]...), NewDeque(ints[n1:]...)
+			d := d1.Concat(d2)
+			assertDeque(t, ints, d)
+			assertDeque(t, ints[:n1], d1)
+			assertDeque(t, ints[n1:], d2)
+
+			for i := 0; i <= len(ints)+1; i++ {
+				l, r := d.SplitAt(uint64(i))
+				j := min(i, len(ints))
+				assertDeque(t, ints[:j], l)
+				assertDeque(t, ints[j:], r)
+			}
+		}
+	}
+
+	// Deques built up by repeated concatenation, rather than pushing, are
+	// split the same way
+	var d *Deque
+	for i := 0; i < 50; i++ {
+		d = d.Concat(NewDeque(intsTo(i)...))
+	}
+	var ints []interface{}
+	for i := 0; i < 50; i++ {
+		ints = append(ints, intsTo(i)...)
+	}
+	assertDeque(t, ints, d)
+	for _, i := range []int{0, 1, 100, 600, 1224, 1225} {
+		l, r := d.SplitAt(uint64(i))
+		assertDeque(t, ints[:i], l)
+		assertDeque(t, ints[i:], r)
+		assertDeque(t, ints, l.Concat(r))
+	}
+}
+
+// Test that Deques are equal based only on their elements, regardless of how
+// they were built
+func TestDequeEqual(t *T) {
+	d1 := NewDeque(intsTo(100)...)
+	d2 := NewDeque(intsTo(50)...).Concat(NewDeque(intsTo(100)[50:]...))
+	_, d3, _ := d1.PushFront(-1).PopFront()
+
+	assert.Equal(t, true, d1.Equal(d2))
+	assert.Equal(t, true, d2.Equal(d3))
+	assert.Equal(t, d1.Hash(0), d2.Hash(0))
+	assert.Equal(t, false, d1.Equal(d2.PushBack(100)))
+	_, d4, _ := d1.PopBack()
+	assert.Equal(t, false, d1.Equal(d4.PushBack(-1)))
+	assert.Equal(t, false, d1.Equal(ToVector(d1)))
+	assert.Equal(t, true, (*Deque)(nil).Equal(NewDeque()))
+}
+
+func BenchmarkDequePushBack(b *B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		var d *Deque
+		for i := 0; i < 1000; i++ {
+			d = d.PushBack(i)
+		}
+	}
+}
+
+func BenchmarkDequeSplitConcat(b *B) {
+	d := NewDeque(intsTo(100000)...)
+	b.ReportAllocs()
+	for b.Loop() {
+		l, r := d.SplitAt(31337)
+		r.Concat(l)
+	}
+}
@@ -0,0 +1,673 @@
+package seq
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ReadEDN and WriteEDN convert between EDN (https://github.com/edn-format/edn)
+// and the types in this package. EDN lists are read as Lists, vectors as
+// Vectors, maps as HashMaps and sets as Sets. Integers are read as ints,
+// floats as float64s, strings as strings, and characters, keywords and
+// symbols as the Char, Keyword and Symbol types. Tagged literals, including
+// the built in #inst and #uuid, are read as *Tagged, leaving interpreting
+// them to the caller.
+
+// Keyword is an EDN keyword, like :foo or :foo/bar. It holds the keyword's name
+// without the leading colon.
+type Keyword string
+
+// String returns the Keyword as it's written in EDN
+func (k Keyword) String() string {
+	return ":" + string(k)
+}
+
+// Symbol is an EDN symbol, like foo or foo/bar
+type Symbol string
+
+// Char is an EDN character, like \a or \newline. It's a distinct type from rune
+// so that it isn't written as an integer.
+type Char rune
+
+// String returns the Char as it's written in EDN
+func (c Char) String() string {
+	buf := new(bytes.Buffer)
+	writeEDNChar(buf, c)
+	return buf.String()
+}
+
+// Tagged is an EDN tagged literal, like #inst "1985-04-12T23:20:50.52Z": a
+// value preceded by a tag naming how it should be interpreted.
+type Tagged struct {
+	Tag Symbol
+	Val interface{}
+}
+
+// Hash implements the Hash method for the Setable interface
+func (t *Tagged) Hash(i uint32) uint32 {
+	return hashCombine(hash(t.Tag, i), hash(t.Val, i))
+}
+
+// Equal implements the Equal method for the Setable interface. Two Taggeds are
+// equal if they have the same tag and equal values.
+func (t *Tagged) Equal(v interface{}) bool {
+	t2, ok := v.(*Tagged)
+	return ok && t.Tag == t2.Tag && equal(t.Val, t2.Val)
+}
+
+// String returns the Tagged as it's written in EDN
+func (t *Tagged) String() string {
+	return fmt.Sprintf("#%s %v", t.Tag, t.Val)
+}
+
+// ErrEDN is the error returned by ReadEDN when its input isn't valid EDN, or
+// holds something which can't be read, like an integer too large for an int
+type ErrEDN struct {
+	// The byte offset into the input at which the problem was found
+	Offset int
+	Msg    string
+}
+
+func (err ErrEDN) Error() string {
+	return fmt.Sprintf("invalid EDN at offset %d: %s", err.Offset, err.Msg)
+}
+
+// ErrEDNType is the error returned by WriteEDN when it's given a value, or a
+// collection holding a value, of a type which has no EDN form
+type ErrEDNType struct {
+	Type reflect.Type
+}
+
+func (err ErrEDNType) Error() string {
+	return fmt.Sprintf("%s can't be written as EDN", err.Type)
+}
+
+// ErrEDNValue is the error returned by WriteEDN when it's given a value, or a
+// collection holding a value, whose type has an EDN form but which ReadEDN
+// wouldn't read back the same: a Keyword or Symbol whose name isn't a valid
+// keyword or symbol, a Tagged whose tag isn't a valid tag, or an integer which
+// doesn't fit in an int.
+type ErrEDNValue struct {
+	Value interface{}
+}
+
+func (err ErrEDNValue) Error() string {
+	return fmt.Sprintf("%#v can't be written as EDN", err.Value)
+}
+
+type ednReader struct {
+	s   string
+	pos int
+}
+
+func (r *ednReader) errorf(format string, args ...interface{}) error {
+	return ErrEDN{r.pos, fmt.Sprintf(format, args...)}
+}
+
+// Moves past any whitespace, commas and comments
+func (r *ednReader) skip() {
+	for r.pos < len(r.s) {
+		switch r.s[r.pos] {
+		case ' ', '\t', '\n', '\r', '\f', ',':
+			r.pos++
+		case ';':
+			for r.pos < len(r.s) && r.s[r.pos] != '\n' {
+				r.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Returns whether the byte ends a token
+func ednDelim(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\f', ',', '(', ')', '[', ']', '{', '}', '"', ';':
+		return true
+	}
+	return false
+}
+
+// Returns the token starting at the current position, and moves past it
+func (r *ednReader) token() string {
+	start := r.pos
+	for r.pos < len(r.s) && !ednDelim(r.s[r.pos]) {
+		r.pos++
+	}
+	return r.s[start:r.pos]
+}
+
+// Reads the next form. Returns false if the form was discarded with #_, in
+// which case there was no value.
+func (r *ednReader) readForm() (interface{}, bool, error) {
+	r.skip()
+	if r.pos >= len(r.s) {
+		return nil, false, r.errorf("unexpected end of input")
+	}
+
+	switch c := r.s[r.pos]; c {
+	case '(':
+		r.pos++
+		els, err := r.readColl(')')
+		return NewList(els...), true, err
+	case '[':
+		r.pos++
+		els, err := r.readColl(']')
+		return NewVector(els...), true, err
+	case '{':
+		r.pos++
+		return r.readMap()
+	case ')', ']', '}':
+		return nil, false, r.errorf("unexpected %q", c)
+	case '"':
+		r.pos++
+		return r.readString()
+	case '\\':
+		r.pos++
+		return r.readChar()
+	case '#':
+		r.pos++
+		return r.readDispatch()
+	}
+
+	start := r.pos
+	tok := r.token()
+	val, err := ednAtom(tok)
+	if err != nil {
+		r.pos = start
+		return nil, false, r.errorf("%s", err)
+	}
+	return val, true, nil
+}
+
+// Reads forms until one which isn't discarded
+func (r *ednReader) readValue() (interface{}, error) {
+	for {
+		if val, ok, err := r.readForm(); err != nil || ok {
+			return val, err
+		}
+	}
+}
+
+// Reads the values of a collection up to and including the given closing
+// delimiter
+func (r *ednReader) readColl(end byte) ([]interface{}, error) {
+	var els []interface{}
+	for {
+		r.skip()
+		if r.pos >= len(r.s) {
+			return nil, r.errorf("unexpected end of input, expected %q", end)
+		} else if r.s[r.pos] == end {
+			r.pos++
+			return els, nil
+		}
+		el, ok, err := r.readForm()
+		if err != nil {
+			return nil, err
+		} else if ok {
+			els = append(els, el)
+		}
+	}
+}
+
+func (r *ednReader) readMap() (interface{}, bool, error) {
+	start := r.pos
+	els, err := r.readColl('}')
+	if err != nil {
+		return nil, false, err
+	} else if len(els)%2 != 0 {
+		return nil, false, ErrEDN{start, "map has a key with no value"}
+	}
+
+	t := (*HashMap)(nil).Transient()
+	for i := 0; i < len(els); i += 2 {
+		if ok, err := t.TrySet(els[i], els[i+1]); err != nil {
+			return nil, false, ErrEDN{start, err.Error()}
+		} else if !ok {
+			return nil, false, ErrEDN{start, fmt.Sprintf("map has duplicate key %v", els[i])}
+		}
+	}
+	return t.Persistent(), true, nil
+}
+
+func (r *ednReader) readSet() (interface{}, bool, error) {
+	start := r.pos
+	els, err := r.readColl('}')
+	if err != nil {
+		return nil, false, err
+	}
+
+	t := (*Set)(nil).Transient()
+	for i := range els {
+		if ok, err := t.TrySetVal(els[i]); err != nil {
+			return nil, false, ErrEDN{start, err.Error()}
+		} else if !ok {
+			return nil, false, ErrEDN{start, fmt.Sprintf("set has duplicate element %v", els[i])}
+		}
+	}
+	return t.Persistent(), true, nil
+}
+
+// Reads what follows a #: a set, a discarded form, a symbolic value, or a
+// tagged literal
+func (r *ednReader) readDispatch() (interface{}, bool, error) {
+	if r.pos >= len(r.s) {
+		return nil, false, r.errorf("unexpected end of input after #")
+	}
+
+	switch r.s[r.pos] {
+	case '{':
+		r.pos++
+		return r.readSet()
+	case '_':
+		r.pos++
+		_, err := r.readValue()
+		return nil, false, err
+	case '#':
+		r.pos++
+		start := r.pos
+		switch tok := r.token(); tok {
+		case "Inf":
+			return math.Inf(1), true, nil
+		case "-Inf":
+			return math.Inf(-1), true, nil
+		case "NaN":
+			return math.NaN(), true, nil
+		default:
+			r.pos = start
+			return nil, false, r.errorf("unknown symbolic value ##%s", tok)
+		}
+	}
+
+	start := r.pos
+	tag := r.token()
+	if tag == "" || !unicode.IsLetter(rune(tag[0])) {
+		r.pos = start
+		return nil, false, r.errorf("invalid dispatch #%s", tag)
+	} else if !ednAtomIs(tag, Symbol(tag)) {
+		r.pos = start
+		return nil, false, r.errorf("invalid tag #%s", tag)
+	}
+	val, err := r.readValue()
+	if err != nil {
+		return nil, false, err
+	}
+	return &Tagged{Symbol(tag), val}, true, nil
+}
+
+func (r *ednReader) readString() (interface{}, bool, error) {
+	buf := new(strings.Builder)
+	for r.pos < len(r.s) {
+		c := r.s[r.pos]
+		r.pos++
+		switch c {
+		case '"':
+			return buf.String(), true, nil
+		case '\\':
+			if r.pos >= len(r.s) {
+				return nil, false, r.errorf("unexpected end of input in string")
+			}
+			esc := r.s[r.pos]
+			r.pos++
+			switch esc {
+			case 't':
+				buf.WriteByte('\t')
+			case 'r':
+				buf.WriteByte('\r')
+			case 'n':
+				buf.WriteByte('\n')
+			case 'b':
+				buf.WriteByte('\b')
+			case 'f':
+				buf.WriteByte('\f')
+			case '\\', '"':
+				buf.WriteByte(esc)
+			case 'u':
+				if r.pos+4 > len(r.s) {
+					return nil, false, r.errorf("invalid unicode escape in string")
+				}
+				u, err := strconv.ParseUint(r.s[r.pos:r.pos+4], 16, 16)
+				if err != nil {
+					return nil, false, r.errorf("invalid unicode escape in string")
+				}
+				r.pos += 4
+				buf.WriteRune(rune(u))
+			default:
+				r.pos--
+				return nil, false, r.errorf("invalid escape \\%c in string", esc)
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return nil, false, r.errorf("unexpected end of input in string")
+}
+
+var ednCharNames = map[string]Char{
+	"newline":   '\n',
+	"return":    '\r',
+	"space":     ' ',
+	"tab":       '\t',
+	"formfeed":  '\f',
+	"backspace": '\b',
+}
+
+func (r *ednReader) readChar() (interface{}, bool, error) {
+	start := r.pos
+	if r.pos >= len(r.s) {
+		return nil, false, r.errorf("unexpected end of input after \\")
+	}
+
+	// The first character is always part of the Char, even if it's a
+	// delimiter
+	_, size := utf8.DecodeRuneInString(r.s[r.pos:])
+	r.pos += size
+	tok := r.s[start:r.pos] + r.token()
+
+	if c, n := utf8.DecodeRuneInString(tok); n == len(tok) {
+		return Char(c), true, nil
+	} else if c, ok := ednCharNames[tok]; ok {
+		return c, true, nil
+	} else if len(tok) == 5 && tok[0] == 'u' {
+		if u, err := strconv.ParseUint(tok[1:], 16, 16); err == nil {
+			return Char(u), true, nil
+		}
+	}
+	r.pos = start
+	return nil, false, r.errorf("invalid character \\%s", tok)
+}
+
+// Returns whether the rune may appear in a symbol or keyword
+func ednSymbolRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune(".*+!-_?$%&=<>/:#'", c)
+}
+
+// Parses a token which isn't a collection, string or character
+func ednAtom(tok string) (interface{}, error) {
+	switch tok {
+	case "nil":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+
+	if c := tok[0]; c >= '0' && c <= '9' ||
+		(c == '+' || c == '-') && len(tok) > 1 && tok[1] >= '0' && tok[1] <= '9' {
+		return ednNumber(tok)
+	}
+
+	name := tok
+	if tok[0] == ':' {
+		name = tok[1:]
+	}
+	if name == "" || name[0] == ':' || name[0] == '#' || strings.HasPrefix(name, "/") && name != "/" {
+		return nil, fmt.Errorf("invalid symbol %s", tok)
+	}
+	for _, c := range name {
+		if !ednSymbolRune(c) {
+			return nil, fmt.Errorf("invalid symbol %s", tok)
+		}
+	}
+	if tok[0] == ':' {
+		return Keyword(name), nil
+	}
+	return Symbol(name), nil
+}
+
+// Returns whether ednAtom parses the token as the given value
+func ednAtomIs(tok string, v interface{}) bool {
+	if tok == "" {
+		return false
+	}
+	atom, err := ednAtom(tok)
+	return err == nil && atom == v
+}
+
+func ednNumber(tok string) (interface{}, error) {
+	if strings.ContainsAny(tok, ".eE") || strings.HasSuffix(tok, "M") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(tok, "M"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %s", tok)
+		}
+		return f, nil
+	}
+
+	i, err := strconv.ParseInt(strings.TrimSuffix(tok, "N"), 10, strconv.IntSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integer %s", tok)
+	}
+	return int(i), nil
+}
+
+// ReadEDN reads the single EDN value in the given string, which may be
+// surrounded by whitespace and comments. See the top of this file for which
+// types each kind of EDN value is read as. Maps and sets with duplicate keys or
+// elements are errors, as are integers which don't fit in an int, which
+// includes those with the N suffix. Floats with the M suffix are read as
+// float64s. Returns an ErrEDN if the string can't be read.
+func ReadEDN(s string) (interface{}, error) {
+	r := &ednReader{s: s}
+	val, err := r.readValue()
+	if err != nil {
+		return nil, err
+	}
+
+	// There may be discarded forms left, but nothing else
+	for {
+		r.skip()
+		start := r.pos
+		if r.pos >= len(r.s) {
+			return val, nil
+		} else if _, ok, err := r.readForm(); err != nil {
+			return nil, err
+		} else if ok {
+			return nil, ErrEDN{start, "more than one value"}
+		}
+	}
+}
+
+func writeEDNString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			if c < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, c)
+			} else {
+				buf.WriteRune(c)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+func writeEDNChar(buf *bytes.Buffer, c Char) {
+	buf.WriteByte('\\')
+	for name, nc := range ednCharNames {
+		if c == nc {
+			buf.WriteString(name)
+			return
+		}
+	}
+	if unicode.IsGraphic(rune(c)) || c > 0xffff {
+		buf.WriteRune(rune(c))
+	} else {
+		fmt.Fprintf(buf, "u%04x", rune(c))
+	}
+}
+
+func writeEDNFloat(buf *bytes.Buffer, f float64, bitSize int) {
+	switch {
+	case math.IsInf(f, 1):
+		buf.WriteString("##Inf")
+	case math.IsInf(f, -1):
+		buf.WriteString("##-Inf")
+	case math.IsNaN(f):
+		buf.WriteString("##NaN")
+	default:
+		s := strconv.FormatFloat(f, 'g', -1, bitSize)
+		buf.WriteString(s)
+		if !strings.ContainsAny(s, ".e") {
+			buf.WriteString(".0")
+		}
+	}
+}
+
+// Writes the elements of the Seq separated by spaces, and wrapped in the given
+// delimiters. If the Seq is an ErrSeq it's walked with FirstRest, so that the
+// error which ended it, if any, can be returned.
+func writeEDNSeq(buf *bytes.Buffer, s Seq, start, end string) error {
+	els := Values(s)
+	if _, ok := s.(ErrSeq); ok {
+		elsSlice, err := ToSliceErr(s)
+		if err != nil {
+			return err
+		}
+		els = slices.Values(elsSlice)
+	}
+
+	buf.WriteString(start)
+	first := true
+	for el := range els {
+		if !first {
+			buf.WriteByte(' ')
+		}
+		first = false
+		if err := writeEDN(buf, el); err != nil {
+			return err
+		}
+	}
+	buf.WriteString(end)
+	return nil
+}
+
+// Writes the KVs of the Seq as an EDN map
+func writeEDNKVs(buf *bytes.Buffer, s Seq) error {
+	buf.WriteByte('{')
+	first := true
+	for el := range Values(s) {
+		kv := el.(*KV)
+		if !first {
+			buf.WriteString(", ")
+		}
+		first = false
+		if err := writeEDN(buf, kv.Key); err != nil {
+			return err
+		}
+		buf.WriteByte(' ')
+		if err := writeEDN(buf, kv.Val); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeEDN(buf *bytes.Buffer, v interface{}) error {
+	switch vt := v.(type) {
+	case nil:
+		buf.WriteString("nil")
+	case bool:
+		buf.WriteString(strconv.FormatBool(vt))
+	case string:
+		writeEDNString(buf, vt)
+	case Char:
+		writeEDNChar(buf, vt)
+	case Keyword:
+		if !ednAtomIs(vt.String(), vt) {
+			return ErrEDNValue{vt}
+		}
+		buf.WriteString(vt.String())
+	case Symbol:
+		if !ednAtomIs(string(vt), vt) {
+			return ErrEDNValue{vt}
+		}
+		buf.WriteString(string(vt))
+	case *Tagged:
+		// The same as readDispatch's checks on a tag
+		if !ednAtomIs(string(vt.Tag), vt.Tag) || !unicode.IsLetter(rune(vt.Tag[0])) {
+			return ErrEDNValue{vt}
+		}
+		buf.WriteByte('#')
+		buf.WriteString(string(vt.Tag))
+		buf.WriteByte(' ')
+		return writeEDN(buf, vt.Val)
+	case int, int8, int16, int32, int64:
+		i := reflect.ValueOf(v).Int()
+		if i < math.MinInt || i > math.MaxInt {
+			return ErrEDNValue{v}
+		}
+		buf.WriteString(strconv.FormatInt(i, 10))
+	case uint, uint8, uint16, uint32, uint64:
+		u := reflect.ValueOf(v).Uint()
+		if u > math.MaxInt {
+			return ErrEDNValue{v}
+		}
+		buf.WriteString(strconv.FormatUint(u, 10))
+	case float32:
+		writeEDNFloat(buf, float64(vt), 32)
+	case float64:
+		writeEDNFloat(buf, vt, 64)
+	case *List:
+		return writeEDNSeq(buf, vt, "(", ")")
+	case *Vector:
+		return writeEDNSeq(buf, vt, "[", "]")
+	case *Set:
+		return writeEDNSeq(buf, vt, "#{", "}")
+	case *SortedSet:
+		return writeEDNSeq(buf, vt, "#{", "}")
+	case *HashMap:
+		return writeEDNKVs(buf, vt)
+	case *OrderedMap:
+		return writeEDNKVs(buf, vt)
+	case *SortedMap:
+		return writeEDNKVs(buf, vt)
+	case Seq:
+		return writeEDNSeq(buf, vt, "(", ")")
+	default:
+		return ErrEDNType{reflect.TypeOf(v)}
+	}
+	return nil
+}
+
+// WriteEDN returns the given value written as EDN. Any value made up of the
+// types ReadEDN returns can be written, and reading it back with ReadEDN gives
+// a value Equal to it. Ints, uints and floats of every size can be written
+// too, though they're read back as ints and float64s. SortedSets are written
+// as sets and OrderedMaps and SortedMaps as maps, and any other Seq as a list,
+// so they're read back as Sets, HashMaps and Lists. If a Seq ends with an
+// error that error is returned. Returns an ErrEDNType if the value is, or
+// holds, anything else, and an ErrEDNValue if it is or holds a value which
+// ReadEDN wouldn't read back the same, like Symbol("nil") or an integer too
+// large for an int.
+func WriteEDN(v interface{}) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := writeEDN(buf, v); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,247 @@
+package seq
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test reading each kind of EDN value
+func TestReadEDN(t *T) {
+	assertRead := func(expected interface{}, s string) {
+		val, err := ReadEDN(s)
+		assert.Nil(t, err)
+		assert.Equal(t, true, equal(expected, val))
+	}
+
+	assertRead(nil, "nil")
+	assertRead(true, "true")
+	assertRead(false, " false ")
+	assertRead(42, "42")
+	assertRead(-7, "-7")
+	assertRead(7, "+7")
+	assertRead(7, "7N")
+	assertRead(1.5, "1.5")
+	assertRead(-1e10, "-1e10")
+	assertRead(2.5, "2.5M")
+	assertRead(math.Inf(1), "##Inf")
+	assertRead(math.Inf(-1), "##-Inf")
+	assertRead("a \"b\"\n\tcé", `"a \"b\"\n\tcé"`)
+	assertRead(Char('a'), `\a`)
+	assertRead(Char('('), `\(`)
+	assertRead(Char('\n'), `\newline`)
+	assertRead(Char(' '), `\space`)
+	assertRead(Char('é'), `\é`)
+	assertRead(Keyword("foo"), ":foo")
+	assertRead(Keyword("foo/bar"), ":foo/bar")
+	assertRead(Symbol("foo"), "foo")
+	assertRead(Symbol("my.ns/foo?"), "my.ns/foo?")
+	assertRead(Symbol("/"), "/")
+	assertRead(Symbol("-"), "-")
+	assertRead(&Tagged{"inst", "1985-04-12T23:20:50.52Z"}, `#inst "1985-04-12T23:20:50.52Z"`)
+	assertRead(&Tagged{"my/tag", NewVector(1, 2)}, `#my/tag [1 2]`)
+
+	assertRead(NewList(1, Symbol("a"), NewList()), "(1 a ())")
+	assertRead(NewVector(1, NewVector(2)), "[1, [2]]")
+	assertRead(NewSet(1, Keyword("a"), NewList(1)), "#{1 :a (1)}")
+	assertRead(
+		NewHashMap(KeyVal(Keyword("a"), 1), KeyVal(NewVector(1, 2), NewSet())),
+		"{:a 1, [1 2] #{}}",
+	)
+
+	// Comments and discarded forms
+	assertRead(NewList(1, 3), `(1 ; two
+		#_2 3 #_ #_ 4 5) ; done`)
+	assertRead(NewVector(), "[#_ (1 2)]")
+	assertRead(1, "#_0 1 #_2")
+
+	nan, err := ReadEDN("##NaN")
+	assert.Nil(t, err)
+	assert.Equal(t, true, math.IsNaN(nan.(float64)))
+}
+
+// Test that invalid EDN returns an ErrEDN at the right offset
+func TestReadEDNErrors(t *T) {
+	assertErr := func(offset int, s string) {
+		_, err := ReadEDN(s)
+		ednErr, ok := err.(ErrEDN)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, offset, ednErr.Offset)
+	}
+
+	assertErr(0, "")
+	assertErr(3, " ; ")
+	assertErr(0, ")")
+	assertErr(4, "(1 2")
+	assertErr(2, "[1}")
+	assertErr(2, "1 2")
+	assertErr(4, `"abc`)
+	assertErr(2, `"\q"`)
+	assertErr(1, `\foo`)
+	assertErr(0, "99999999999999999999")
+	assertErr(0, "1.2.3")
+	assertErr(0, "::a")
+	assertErr(0, ":")
+	assertErr(1, "{:a}")
+	assertErr(1, "{:a 1 :a 2}")
+	assertErr(2, "#{1 1}")
+	assertErr(2, "##Foo")
+	assertErr(1, "#1 2")
+	assertErr(4, "#tag")
+	assertErr(2, "#_")
+}
+
+// Test writing values as EDN
+func TestWriteEDN(t *T) {
+	assertWrite := func(expected string, v interface{}) {
+		s, err := WriteEDN(v)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, s)
+	}
+
+	assertWrite("nil", nil)
+	assertWrite("true", true)
+	assertWrite("-3", int8(-3))
+	assertWrite("3", uint64(3))
+	assertWrite("1.0", 1.0)
+	assertWrite("1.5", float32(1.5))
+	assertWrite("1e+21", 1e21)
+	assertWrite("##-Inf", math.Inf(-1))
+	assertWrite(`"a\"\\\n\u0001"`, "a\"\\\n\x01")
+	assertWrite(`\a`, Char('a'))
+	assertWrite(`\newline`, Char('\n'))
+	assertWrite(`\u0001`, Char(1))
+	assertWrite(":a/b", Keyword("a/b"))
+	assertWrite("a/b", Symbol("a/b"))
+	assertWrite(`#uuid "x"`, &Tagged{"uuid", "x"})
+	assertWrite("(1 [2 3] ())", NewList(1, NewVector(2, 3), NewList()))
+	assertWrite("#{1}", NewSet(1))
+	assertWrite("#{1 2 3}", NewSortedSet(compareInts, 3, 2, 1))
+	assertWrite("{:b 2, :a 1}", NewOrderedMap(KeyVal(Keyword("b"), 2), KeyVal(Keyword("a"), 1)))
+	assertWrite("(1 2)", NewQueue(1, 2))
+	assertWrite("{}", NewHashMap())
+	assertWrite("(1 2)", ToLazy(NewList(1, 2)))
+	assertWrite("[(1 2) #{} ()]", NewVector(NewDeque(1, 2), NewSet(), NewList()))
+
+	// A Lazy ending in an error returns it, wherever it is
+	errBoom := errors.New("boom")
+	l := NewLazyErr(func() (interface{}, ErrThunk, bool, error) {
+		return 1, func() (interface{}, ErrThunk, bool, error) {
+			return nil, nil, false, errBoom
+		}, true, nil
+	})
+	_, err := WriteEDN(NewVector(1, l))
+	assert.Equal(t, errBoom, err)
+
+	_, err = WriteEDN(NewList(1, []int{2}))
+	assert.Equal(t, ErrEDNType{reflect.TypeOf([]int{})}, err)
+	_, err = WriteEDN(NewHashMap(KeyVal("a", struct{}{})))
+	assert.Equal(t, ErrEDNType{reflect.TypeOf(struct{}{})}, err)
+
+	// Values which wouldn't be read back the same
+	assertWrite("9223372036854775807", uint64(math.MaxInt64))
+	badTag := &Tagged{"1t", 1}
+	for _, v := range []interface{}{
+		Symbol("nil"), Symbol("true"), Symbol(""), Symbol("a b"), Symbol("1a"),
+		Symbol(":a"), Symbol("#a"), Symbol("/a"), Keyword(""), Keyword("a b"),
+		Keyword(":a"), Keyword("a(b"), badTag, uint64(math.MaxInt64) + 1,
+	} {
+		_, err = WriteEDN(NewVector(1, v))
+		assert.Equal(t, ErrEDNValue{v}, err)
+	}
+}
+
+// Returns a random value made up of the types ReadEDN returns, nested at most
+// depth deep
+func randEDN(r *rand.Rand, depth int) interface{} {
+	n := 10
+	if depth > 0 {
+		n = 14
+	}
+	switch r.Intn(n) {
+	case 0:
+		return nil
+	case 1:
+		return r.Intn(2) == 0
+	case 2:
+		return r.Intn(2000) - 1000
+	case 3:
+		return r.NormFloat64() * math.Pow(10, float64(r.Intn(40)-20))
+	case 4:
+		b := make([]rune, r.Intn(5))
+		for i := range b {
+			b[i] = []rune("a\"\\\n\té\x01 ;)")[r.Intn(10)]
+		}
+		return string(b)
+	case 5:
+		return Char([]rune("a(\n é\x01\\")[r.Intn(7)])
+	case 6:
+		return Keyword([]string{"a", "b/c", "d?"}[r.Intn(3)])
+	case 7:
+		return Symbol([]string{"x", "y/z", "+", "/"}[r.Intn(4)])
+	case 8:
+		return math.Inf(1 - 2*r.Intn(2))
+	case 9:
+		return &Tagged{"t", r.Intn(10)}
+	}
+
+	els := make([]interface{}, r.Intn(5))
+	for i := range els {
+		els[i] = randEDN(r, depth-1)
+	}
+	switch n - 1 - r.Intn(4) {
+	case 10:
+		return NewList(els...)
+	case 11:
+		return NewVector(els...)
+	case 12:
+		return NewSet(els...)
+	default:
+		hm := NewHashMap()
+		for i := 0; i+1 < len(els); i += 2 {
+			hm, _ = hm.Set(els[i], els[i+1])
+		}
+		return hm
+	}
+}
+
+// Test that reading back what's written gives an equal value
+func TestEDNRoundTrip(t *T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		v := randEDN(r, 4)
+		s, err := WriteEDN(v)
+		assert.Nil(t, err)
+		v2, err := ReadEDN(s)
+		assert.Nil(t, err)
+		if !equal(v, v2) {
+			t.Fatalf("%s read back as %v", s, v2)
+		}
+	}
+	// Names and tags are either read back the same or can't be written
+	names := []string{
+		"", "a", "a b", "nil", "true", "false", "1a", "+1", "-1", "+a", "-",
+		"/", "a/b", "/a", ":a", "#a", "a#b", "a,b", "a;b", `a"b`, "é", ".5",
+		"a\\b", "a:b", "'a",
+	}
+	for _, name := range names {
+		for _, v := range []interface{}{
+			Symbol(name), Keyword(name), &Tagged{Symbol(name), 1},
+		} {
+			s, err := WriteEDN(v)
+			if err != nil {
+				assert.Equal(t, ErrEDNValue{v}, err)
+				continue
+			}
+			v2, err := ReadEDN(s)
+			assert.Nil(t, err)
+			if !equal(v, v2) {
+				t.Fatalf("%s read back as %v", s, v2)
+			}
+		}
+	}
+}
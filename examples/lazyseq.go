@@ -13,21 +13,24 @@ import (
 )
 
 // Thunks are weird, but they are what's needed in order to create Lazys. This
-// one is a wrapper around bufio.Reader
-func seqIoThunk(reader *bufio.Reader, delim byte) seq.Thunk {
-	return func() (interface{}, seq.Thunk, bool) {
+// one is a wrapper around bufio.Reader. It's an ErrThunk, so that errors
+// reading from the reader aren't mistaken for the end of the sequence.
+func seqIoThunk(reader *bufio.Reader, delim byte) seq.ErrThunk {
+	return func() (interface{}, seq.ErrThunk, bool, error) {
 		data, err := reader.ReadString(delim)
-		if err != nil {
-			return nil, nil, false
+		if err == io.EOF && data == "" {
+			return nil, nil, false, nil
+		} else if err != nil && err != io.EOF {
+			return nil, nil, false, err
 		}
 		tdata := strings.TrimRight(data, "\n")
-		return tdata, seqIoThunk(reader, delim), true
+		return tdata, seqIoThunk(reader, delim), true, nil
 	}
 }
 
 func NewSeqIoReader(read io.Reader, delim byte) *seq.Lazy {
 	thunk := seqIoThunk(bufio.NewReader(read), delim)
-	return seq.NewLazy(thunk)
+	return seq.NewLazyErr(thunk)
 }
 
 func main() {
@@ -39,9 +42,16 @@ func main() {
 	}
 	sir := NewSeqIoReader(file, '\n')
 
-	// The first time we call Println the file will be read fully. The second
-	// time it's fully read, but lazy lists are cached so we can see the results
-	// again
-	fmt.Println(sir)
+	// The first time we call ToSliceErr the file will be read fully. If
+	// reading failed part way through we'll get back what was read up till
+	// then, along with the error.
+	lines, err := seq.ToSliceErr(sir)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(lines)
+
+	// The second time it's fully read, but lazy lists are cached so we can see
+	// the results again
 	fmt.Println(sir)
 }
@@ -47,13 +47,25 @@ type HashMap struct {
 // NewHashMap returns a new HashMap of the given KVs (or possibly just an empty
 // HashMap)
 func NewHashMap(kvs ...*KV) *HashMap {
+	hm, err := TryNewHashMap(kvs...)
+	if err != nil {
+		panic(err)
+	}
+	return hm
+}
+
+// TryNewHashMap is like NewHashMap, but returns an ErrUnhashable if any of the
+// given keys can't be hashed, rather than panicking
+func TryNewHashMap(kvs ...*KV) (*HashMap, error) {
 	ints := make([]interface{}, len(kvs))
 	for i := range kvs {
 		ints[i] = kvs[i]
 	}
-	return &HashMap{
-		set: NewSet(ints...),
+	set, err := TryNewSet(ints...)
+	if err != nil {
+		return nil, err
 	}
+	return &HashMap{set: set}, nil
 }
 
 // Hash implements the Hash method for the Setable interface
@@ -71,18 +83,14 @@ func (hm *HashMap) Equal(v interface{}) bool {
 		return false
 	}
 
-	s := Seq(hm)
-	var el interface{}
-	for {
-		el, s, ok = s.FirstRest()
-		if !ok {
-			return true
-		}
+	eq := true
+	hm.set.each(func(el interface{}) bool {
 		kv := el.(*KV)
-		if v2, ok := hm2.Get(kv.Key); !ok || !equal(kv.Val, v2) {
-			return false
-		}
-	}
+		v2, ok := hm2.Get(kv.Key)
+		eq = ok && equal(kv.Val, v2)
+		return eq
+	})
+	return eq
 }
 
 // FirstRest is an implementation of FirstRest for Seq interface. First return
@@ -100,37 +108,78 @@ func (hm *HashMap) FirstRest() (interface{}, Seq, bool) {
 // was already there and was overwritten). Has the same complexity as Set's
 // SetVal method.
 func (hm *HashMap) Set(key, val interface{}) (*HashMap, bool) {
-	if hm == nil {
-		hm = NewHashMap()
+	nhm, ok, err := hm.TrySet(key, val)
+	if err != nil {
+		panic(err)
+	}
+	return nhm, ok
+}
+
+// TrySet is like Set, but returns an ErrUnhashable, and the HashMap unchanged,
+// if the key can't be hashed, rather than panicking
+func (hm *HashMap) TrySet(key, val interface{}) (*HashMap, bool, error) {
+	var set *Set
+	if hm != nil {
+		set = hm.set
 	}
 
-	nset, ok := hm.set.SetVal(KeyVal(key, val))
-	return &HashMap{nset}, ok
+	nset, ok, err := set.TrySetVal(KeyVal(key, val))
+	if err != nil {
+		return hm, false, err
+	}
+	return &HashMap{nset}, ok, nil
 }
 
 // Del returns a new HashMap with the given key removed from it. Also returns
 // whether or not the key was already there (true if so, false if not). Has the
 // same time complexity as Set's DelVal method.
 func (hm *HashMap) Del(key interface{}) (*HashMap, bool) {
-	if hm == nil {
-		hm = NewHashMap()
+	nhm, ok, err := hm.TryDel(key)
+	if err != nil {
+		panic(err)
+	}
+	return nhm, ok
+}
+
+// TryDel is like Del, but returns an ErrUnhashable, and the HashMap unchanged,
+// if the key can't be hashed, rather than panicking
+func (hm *HashMap) TryDel(key interface{}) (*HashMap, bool, error) {
+	var set *Set
+	if hm != nil {
+		set = hm.set
 	}
 
-	nset, ok := hm.set.DelVal(KeyVal(key, nil))
-	return &HashMap{nset}, ok
+	nset, ok, err := set.TryDelVal(KeyVal(key, nil))
+	if err != nil {
+		return hm, false, err
+	}
+	return &HashMap{nset}, ok, nil
 }
 
 // Get returns a value for a given key from the HashMap, along with a boolean
 // indicating whether or not the value was found. Has the same time complexity
 // as Set's GetVal method.
 func (hm *HashMap) Get(key interface{}) (interface{}, bool) {
-	if hm == nil {
-		return nil, false
-	} else if kv, ok := hm.set.GetVal(KeyVal(key, nil)); ok {
-		return kv.(*KV).Val, true
-	} else {
-		return nil, false
+	val, ok, err := hm.TryGet(key)
+	if err != nil {
+		panic(err)
+	}
+	return val, ok
+}
+
+// TryGet is like Get, but returns an ErrUnhashable if the key can't be hashed,
+// rather than panicking
+func (hm *HashMap) TryGet(key interface{}) (interface{}, bool, error) {
+	var set *Set
+	if hm != nil {
+		set = hm.set
 	}
+
+	kv, ok, err := set.TryGetVal(KeyVal(key, nil))
+	if !ok {
+		return nil, false, err
+	}
+	return kv.(*KV).Val, true, nil
 }
 
 // FirstRestKV is the same as FirstRest, but returns values already casted,
@@ -152,3 +201,124 @@ func (hm *HashMap) String() string {
 func (hm *HashMap) Size() uint64 {
 	return hm.set.Size()
 }
+
+// Returns the HashMap's Set, which may be nil
+func (hm *HashMap) kvSet() *Set {
+	if hm == nil {
+		return nil
+	}
+	return hm.set
+}
+
+// Returns a new HashMap with the KV for the given key changed using fn, see
+// setAlterFn, in a single walk down the tree
+func (hm *HashMap) alter(key interface{}, fn setAlterFn) *HashMap {
+	set, kv := hm.kvSet(), KeyVal(key, nil)
+	if set == nil {
+		if kv, ok := fn(nil, false); ok {
+			set, _ = set.SetVal(kv)
+		}
+		return &HashMap{set}
+	}
+
+	root, _ := set.root.alter(kv, hash(kv, 0), 0, fn)
+	if root == set.root {
+		return hm
+	}
+	return &HashMap{setFromRoot(root)}
+}
+
+// Update returns a new HashMap with the value on the given key replaced by the
+// result of calling fn on it, and true. If the key isn't in the HashMap it is
+// returned unchanged, along with false. Only walks the tree, and copies the
+// path to the key, once. Has the same time complexity as Set's SetVal method.
+func (hm *HashMap) Update(key interface{}, fn func(interface{}) interface{}) (*HashMap, bool) {
+	var found bool
+	nhm := hm.alter(key, func(cur interface{}, ok bool) (interface{}, bool) {
+		if found = ok; !ok {
+			return nil, false
+		}
+		return KeyVal(key, fn(cur.(*KV).Val)), true
+	})
+	return nhm, found
+}
+
+// UpdateWithDefault is like Update, but if the key isn't in the HashMap fn is
+// called on def instead, and the result set on the key. For example, to count
+// occurrences:
+//
+//	counts = counts.UpdateWithDefault(word, 0, func(n interface{}) interface{} {
+//		return n.(int) + 1
+//	})
+func (hm *HashMap) UpdateWithDefault(key, def interface{}, fn func(interface{}) interface{}) *HashMap {
+	return hm.alter(key, func(cur interface{}, ok bool) (interface{}, bool) {
+		if !ok {
+			return KeyVal(key, fn(def)), true
+		}
+		return KeyVal(key, fn(cur.(*KV).Val)), true
+	})
+}
+
+// Merge returns a new HashMap holding the KVs of both this HashMap and the
+// given one. Where both have a key the value in the given HashMap is kept. The
+// two are merged node by node, the same way as with Set's Union, so any parts
+// they have in common, as two versions of the same HashMap will, are shared
+// with the result rather than copied.
+func (hm *HashMap) Merge(hm2 *HashMap) *HashMap {
+	return &HashMap{hm.kvSet().Union(hm2.kvSet())}
+}
+
+// MergeWith is like Merge, but where both HashMaps have a key its value in the
+// result is fn called with the value in this HashMap and the value in the given
+// one. fn is called for every key both HashMaps have, even if they share its
+// KV, as two versions of the same HashMap will, so unlike Merge the parts the
+// two have in common are copied rather than shared.
+func (hm *HashMap) MergeWith(fn func(v1, v2 interface{}) interface{}, hm2 *HashMap) *HashMap {
+	set, set2 := hm.kvSet(), hm2.kvSet()
+	if set == nil {
+		return &HashMap{set2}
+	} else if set2 == nil {
+		return &HashMap{set}
+	}
+
+	root := set.root.union(set2.root, 0, func(v1, v2 interface{}) interface{} {
+		kv1, kv2 := v1.(*KV), v2.(*KV)
+		return KeyVal(kv1.Key, fn(kv1.Val, kv2.Val))
+	})
+	return &HashMap{setFromRoot(root)}
+}
+
+// SelectKeys returns a new HashMap holding only those KVs of this one whose key
+// is one of the given keys. Completes in O(M*log(N)) time, with M being the
+// number of given keys.
+func (hm *HashMap) SelectKeys(keys ...interface{}) *HashMap {
+	t := NewHashMap().Transient()
+	for _, key := range keys {
+		if val, ok := hm.Get(key); ok {
+			t.Set(key, val)
+		}
+	}
+	return t.Persistent()
+}
+
+// Keys returns a Seq of the keys in the HashMap, in the same order as the
+// HashMap's KVs are iterated in. Completes in O(N) time.
+func (hm *HashMap) Keys() Seq {
+	var l *List
+	hm.kvSet().each(func(el interface{}) bool {
+		l = l.Prepend(el.(*KV).Key)
+		return true
+	})
+	return Reverse(l)
+}
+
+// Vals returns a Seq of the values in the HashMap, in the same order as Keys
+// returns their keys. Completes in O(N) time.
+func (hm *HashMap) Vals() Seq {
+	var l *List
+	hm.kvSet().each(func(el interface{}) bool {
+		l = l.Prepend(el.(*KV).Val)
+		return true
+	})
+	return Reverse(l)
+}
@@ -1,6 +1,8 @@
 package seq
 
 import (
+	"math/rand"
+	"reflect"
 	. "testing"
 
 	"github.com/stretchr/testify/assert"
@@ -153,3 +155,232 @@ func TestHashMapEqual(t *T) {
 	assert.Equal(t, false, hm1.Equal(hm2))
 	assert.Equal(t, false, hm2.Equal(hm1))
 }
+
+func BenchmarkHashMapEqual(b *B) {
+	hm1, hm2 := NewHashMap(), NewHashMap()
+	for i := 0; i < 1000; i++ {
+		hm1, _ = hm1.Set(i, i)
+		hm2, _ = hm2.Set(i, i)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		hm1.Equal(hm2)
+	}
+}
+
+// Test that the Try methods return an ErrUnhashable for keys which can't be
+// hashed, even when the HashMap is empty
+func TestHashMapUnhashable(t *T) {
+	bad := []int{1}
+	expErr := ErrUnhashable{reflect.TypeOf(bad)}
+
+	_, err := TryNewHashMap(KeyVal(1, 1), KeyVal(bad, 2))
+	assert.Equal(t, expErr, err)
+
+	var m *HashMap
+	for _, m = range []*HashMap{m, NewHashMap(KeyVal(1, 1))} {
+		m2, ok, err := m.TrySet(bad, 2)
+		assert.Equal(t, expErr, err)
+		assert.False(t, ok)
+		assert.True(t, m == m2)
+
+		m2, ok, err = m.TryDel(bad)
+		assert.Equal(t, expErr, err)
+		assert.False(t, ok)
+		assert.True(t, m == m2)
+
+		_, ok, err = m.TryGet(bad)
+		assert.Equal(t, expErr, err)
+		assert.False(t, ok)
+	}
+
+	// Unhashable values are fine, only keys need to be hashable
+	m, ok, err := m.TrySet(2, bad)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	v, ok, err := m.TryGet(2)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, bad, v)
+
+	assert.Panics(t, func() { m.Set(bad, 1) })
+}
+
+// Test that Size always matches the real number of KVs in a HashMap, by making
+// random changes to one and to a map alongside it
+func TestHashMapModel(t *T) {
+	r := rand.New(rand.NewSource(1))
+	hm, m := NewHashMap(), map[int]int{}
+	for i := 0; i < 3000; i++ {
+		k, v := r.Intn(300), r.Int()
+		switch r.Intn(4) {
+		case 0, 1:
+			var ok bool
+			hm, ok = hm.Set(k, v)
+			_, had := m[k]
+			assert.Equal(t, !had, ok)
+			m[k] = v
+		case 2:
+			var ok bool
+			hm, ok = hm.Del(k)
+			_, had := m[k]
+			assert.Equal(t, had, ok)
+			delete(m, k)
+		case 3:
+			kv, rest, ok := hm.FirstRestKV()
+			assert.Equal(t, len(m) > 0, ok)
+			if ok {
+				delete(m, kv.Key.(int))
+				hm = rest
+			}
+		}
+
+		assert.Equal(t, uint64(len(m)), hm.Size())
+		assert.Equal(t, len(m), len(ToSlice(hm)))
+		for k, v := range m {
+			got, ok := hm.Get(k)
+			assert.True(t, ok)
+			assert.Equal(t, v, got)
+		}
+	}
+}
+
+// Test updating the values on keys in place, including keys whose hashes
+// collide
+func TestHashMapUpdate(t *T) {
+	inc := func(v interface{}) interface{} { return v.(int) + 1 }
+	hm := NewHashMap()
+	for i := 0; i < 1000; i++ {
+		hm, _ = hm.Set(i, i)
+		hm, _ = hm.Set(collider(i%20), i)
+	}
+
+	for i := 0; i < 1000; i += 7 {
+		prev := hm
+		var ok bool
+		hm, ok = hm.Update(i, inc)
+		assert.Equal(t, true, ok)
+		v, _ := hm.Get(i)
+		assert.Equal(t, i+1, v)
+		v, _ = prev.Get(i)
+		assert.Equal(t, i, v)
+		assert.Equal(t, prev.Size(), hm.Size())
+	}
+	hm2, ok := hm.Update(collider(3), inc)
+	assert.Equal(t, true, ok)
+	v, _ := hm2.Get(collider(3))
+	assert.Equal(t, 984, v)
+
+	// Missing keys leave the HashMap as it was
+	hm2, ok = hm.Update(-1, inc)
+	assert.Equal(t, false, ok)
+	assert.True(t, hm == hm2)
+	hm2, ok = NewHashMap().Update(-1, inc)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, uint64(0), hm2.Size())
+
+	hm2 = hm.UpdateWithDefault(-1, 10, inc).UpdateWithDefault(-1, 10, inc)
+	v, _ = hm2.Get(-1)
+	assert.Equal(t, 12, v)
+	assert.Equal(t, hm.Size()+1, hm2.Size())
+	hm2 = hm2.UpdateWithDefault(collider(50), 0, inc)
+	v, _ = hm2.Get(collider(50))
+	assert.Equal(t, 1, v)
+	assert.Equal(t, hm.Size()+2, hm2.Size())
+	assertSaneSet(t, hm2.set)
+
+	var nilhm *HashMap
+	v, _ = nilhm.UpdateWithDefault("a", 1, inc).Get("a")
+	assert.Equal(t, 2, v)
+}
+
+// Test merging HashMaps, with and without a function for keys both have
+func TestHashMapMerge(t *T) {
+	hm1 := NewHashMap(KeyVal(1, "one"), KeyVal(2, "two"))
+	hm2 := NewHashMap(KeyVal(2, "TWO"), KeyVal(3, "THREE"))
+
+	hm := hm1.Merge(hm2)
+	assertSeqContentsHashMap(t, []*KV{
+		KeyVal(1, "one"), KeyVal(2, "TWO"), KeyVal(3, "THREE"),
+	}, hm)
+	assert.True(t, hm1.Merge(nil).Equal(hm1))
+	assert.True(t, NewHashMap().Merge(hm2).Equal(hm2))
+
+	concat := func(v1, v2 interface{}) interface{} {
+		return v1.(string) + v2.(string)
+	}
+	hm = hm1.MergeWith(concat, hm2)
+	assertSeqContentsHashMap(t, []*KV{
+		KeyVal(1, "one"), KeyVal(2, "twoTWO"), KeyVal(3, "THREE"),
+	}, hm)
+	assert.True(t, hm1.MergeWith(concat, nil).Equal(hm1))
+
+	// fn is called for every key in both, even when they share the KV, so
+	// merging a HashMap with one of its own versions gives the same result as
+	// merging it with an equal HashMap built separately
+	add := func(v1, v2 interface{}) interface{} {
+		return v1.(int) + v2.(int)
+	}
+	base := NewHashMap(KeyVal("a", 1))
+	base2, _ := base.Set("b", 2)
+	expected := NewHashMap(KeyVal("a", 2), KeyVal("b", 2))
+	assert.True(t, base.MergeWith(add, base2).Equal(expected))
+	assert.True(t, base.MergeWith(add, NewHashMap(KeyVal("a", 1), KeyVal("b", 2))).Equal(expected))
+	assert.True(t, base.MergeWith(add, base).Equal(NewHashMap(KeyVal("a", 2))))
+
+	// Merging two versions of a big HashMap calls fn on every key they have in
+	// common, while Merge reuses the parts they share
+	big := NewHashMap()
+	for i := 0; i < 10000; i++ {
+		big, _ = big.Set(i, i)
+	}
+	big1, _ := big.Set(5, 50)
+	big1, _ = big1.Set(-1, -1)
+	big2, _ := big.Set(5, 500)
+	big2, _ = big2.Set(6, 600)
+
+	var calls int
+	sum := func(v1, v2 interface{}) interface{} {
+		calls++
+		return v1.(int) + v2.(int)
+	}
+	merged := big1.MergeWith(sum, big2)
+	assert.Equal(t, 10000, calls)
+	assertSaneSet(t, merged.set)
+	assert.Equal(t, uint64(10001), merged.Size())
+	for k, v := range map[int]int{5: 550, 6: 606, -1: -1, 7: 14} {
+		got, _ := merged.Get(k)
+		assert.Equal(t, v, got)
+	}
+
+	merged = big1.Merge(big2)
+	assertSaneSet(t, merged.set)
+	assert.Equal(t, uint64(10001), merged.Size())
+	var shared int
+	for i, e := range merged.set.root.entries {
+		if e.node != nil && e.node == big.set.root.entries[i].node {
+			shared++
+		}
+	}
+	assert.True(t, shared >= len(big.set.root.entries)-3)
+	assert.True(t, big.Merge(big).set.root == big.set.root)
+}
+
+// Test picking out parts of a HashMap
+func TestHashMapSelectKeysKeysVals(t *T) {
+	hm := NewHashMap(KeyVal(1, "one"), KeyVal(2, "two"), KeyVal(3, "three"))
+
+	assertSeqContentsHashMap(t, []*KV{KeyVal(1, "one"), KeyVal(3, "three")},
+		hm.SelectKeys(1, 3, 4))
+	assert.Equal(t, uint64(0), hm.SelectKeys().Size())
+	assert.Equal(t, uint64(0), hm.SelectKeys(4).Size())
+
+	keys, vals := ToSlice(hm.Keys()), ToSlice(hm.Vals())
+	assertSeqContentsSet(t, []interface{}{1, 2, 3}, hm.Keys())
+	for i := range keys {
+		v, _ := hm.Get(keys[i])
+		assert.Equal(t, v, vals[i])
+	}
+	assert.Equal(t, 0, len(ToSlice(NewHashMap().Keys())))
+	assert.Equal(t, 0, len(ToSlice(NewHashMap().Vals())))
+}
@@ -3,7 +3,8 @@ package seq
 import (
 	"bytes"
 	"fmt"
-	"hash/crc32"
+	"math"
+	"math/bits"
 	"reflect"
 )
 
@@ -14,63 +15,206 @@ import (
 // compared to each other to determine their equality
 type Setable interface {
 
-	// Returns an integer for the value. For two equivalent values (as defined
+	// Returns a 32-bit hash of the value. For two equivalent values (as defined
 	// by Equal) Hash(i) should always return the same number if given the same
-	// i. For multiple values of i, Hash should return different values if
-	// possible.
+	// i, and for different values it should return different numbers as often
+	// as possible, making use of all 32 bits. i acts as a seed; for multiple
+	// values of i Hash should return different values if possible.
 	Hash(uint32) uint32
 
 	// Given an arbitrary value found in a Set, returns whether or not the two
-	// are equal
+	// are equal. If this returns true for a value which isn't itself a
+	// Setable, Hash must return the same as that value's hash, the way KV's
+	// Hash returns its key's hash.
 	Equal(interface{}) bool
 }
 
-// Returns an arbitrary integer for the given value/iteration tuple
+// Mixes the bits of x, so that every bit of the input affects every bit of the
+// output. This is the finalizer from MurmurHash3.
+func mix32(x uint32) uint32 {
+	x ^= x >> 16
+	x *= 0x85ebca6b
+	x ^= x >> 13
+	x *= 0xc2b2ae35
+	x ^= x >> 16
+	return x
+}
+
+// The 64-bit version of mix32, which folds the result down to 32 bits
+func mix64(x uint64) uint32 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return uint32(x)
+}
+
+// FNV-1a over the given bytes, with i mixed into the offset basis
+func hashBytes[S string | []byte](b S, i uint32) uint32 {
+	h := uint32(2166136261) ^ mix32(i)
+	for j := 0; j < len(b); j++ {
+		h ^= uint32(b[j])
+		h *= 16777619
+	}
+	return mix32(h)
+}
+
+// Combines two hashes into one, in a way which depends on their order
+func hashCombine(h1, h2 uint32) uint32 {
+	return h1 ^ (h2 + 0x9e3779b9 + (h1 << 6) + (h1 >> 2))
+}
+
+// Returns the bits of the given float, such that any two floats which are ==
+// to each other have the same bits. -0 is treated as 0, and every NaN is
+// treated the same (even though NaN is never == to anything).
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	} else if f != f {
+		return 0x7ff8000000000001
+	}
+	return math.Float64bits(f)
+}
+
+// Returns a 32-bit hash for the given value/seed tuple. Any value which can be
+// compared using == can be hashed, as well as []byte. Values of the same type
+// which are == to each other will always have the same hash.
 func hash(v interface{}, i uint32) uint32 {
 	switch vt := v.(type) {
 
 	case Setable:
-		return vt.Hash(i) % ARITY
+		return vt.Hash(i)
 
-	case uint32:
-		return (i + vt) % ARITY
+	case nil:
+		return mix32(i)
+
+	case bool:
+		if vt {
+			return hash(uint64(1), i)
+		}
+		return hash(uint64(0), i)
+
+	case uint64:
+		return mix64(vt ^ uint64(i)<<32)
 
 	case uint:
-		return hash(uint32(vt), i)
+		return hash(uint64(vt), i)
 	case uint8:
-		return hash(uint32(vt), i)
-	case uint64:
-		return hash(uint32(vt), i)
+		return hash(uint64(vt), i)
+	case uint16:
+		return hash(uint64(vt), i)
+	case uint32:
+		return hash(uint64(vt), i)
 	case int:
-		return hash(uint32(vt), i)
+		return hash(uint64(vt), i)
 	case int8:
-		return hash(uint32(vt), i)
+		return hash(uint64(vt), i)
 	case int16:
-		return hash(uint32(vt), i)
+		return hash(uint64(vt), i)
 	case int32:
-		return hash(uint32(vt), i)
+		return hash(uint64(vt), i)
 	case int64:
-		return hash(uint32(vt), i)
+		return hash(uint64(vt), i)
 	case float32:
-		return hash(uint32(vt), i)
+		return hash(floatBits(float64(vt)), i)
 	case float64:
-		return hash(uint32(vt), i)
+		return hash(floatBits(vt), i)
+
+	case complex64:
+		return hash(complex128(vt), i)
+	case complex128:
+		return hashCombine(hash(real(vt), i), hash(imag(vt), i))
 
 	case string:
-		return hash([]byte(vt), i)
+		return hashBytes(vt, i)
 
 	case []rune:
-		return hash([]byte(string(vt)), i)
+		return hashBytes(string(vt), i)
 
 	case []byte:
-		return crc32.ChecksumIEEE(vt) % ARITY
+		return hashBytes(vt, i)
 
 	default:
-		err := fmt.Sprintf("%s not hashable", reflect.TypeOf(v))
-		panic(err)
+		rv := reflect.ValueOf(v)
+		if !rv.Comparable() {
+			panic(ErrUnhashable{rv.Type()})
+		}
+		return hashValue(rv, i)
+	}
+}
+
+// Returns a 32-bit hash for the given reflected value. This covers everything
+// which hash doesn't handle itself, like named types, pointers, channels, and
+// structs and arrays whose fields or elements can be hashed. Values are hashed
+// according to how == compares them, so Setable is not used for the fields of
+// structs or elements of arrays.
+func hashValue(rv reflect.Value, i uint32) uint32 {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return hash(nil, i)
+	case reflect.Bool:
+		return hash(rv.Bool(), i)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return hash(uint64(rv.Int()), i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return hash(rv.Uint(), i)
+	case reflect.Float32, reflect.Float64:
+		return hash(rv.Float(), i)
+	case reflect.Complex64, reflect.Complex128:
+		return hash(rv.Complex(), i)
+	case reflect.String:
+		return hashBytes(rv.String(), i)
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return hash(uint64(rv.Pointer()), i)
+	case reflect.Interface:
+		return hashValue(rv.Elem(), i)
+
+	case reflect.Array:
+		h := mix32(i)
+		for j := 0; j < rv.Len(); j++ {
+			h = hashCombine(h, hashValue(rv.Index(j), i))
+		}
+		return h
+
+	case reflect.Struct:
+		h := mix32(i)
+		for j := 0; j < rv.NumField(); j++ {
+			h = hashCombine(h, hashValue(rv.Field(j), i))
+		}
+		return h
+
+	default:
+		panic(ErrUnhashable{rv.Type()})
 	}
 }
 
+// ErrUnhashable is the error returned by the Try methods of Set and HashMap
+// when they're given a value which can't be hashed (see Set for which values
+// can be). The other methods panic with it instead.
+type ErrUnhashable struct {
+	Type reflect.Type
+}
+
+func (err ErrUnhashable) Error() string {
+	return fmt.Sprintf("%s not hashable", err.Type)
+}
+
+// Returns hash(v, 0), or the ErrUnhashable which hash panicked with if v, or
+// something within it, can't be hashed
+func tryHash(v interface{}) (h uint32, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			eu, ok := r.(ErrUnhashable)
+			if !ok {
+				panic(r)
+			}
+			err = eu
+		}
+	}()
+	return hash(v, 0), nil
+}
+
 // Some equalities need one side to a be a type, and sometimes don't care about
 // the other side or need it to be a different type. This function makes those
 // comparisons in a single direction, and will be called twice in equal, once
@@ -104,255 +248,902 @@ func equal(v1, v2 interface{}) bool {
 // The number of children each node in Set (implemented as a hash tree) can have
 const ARITY = 32
 
-// A Set is an implementation of Seq in the form of a persistant hash-tree. All
-// public operations on it return a new, immutable form of the modified
-// variable, leaving the old one intact. Immutability is implemented through
-// node sharing, so operations aren't actually copying the entire hash-tree
-// everytime, only the nodes which change, making the implementation very
-// efficient compared to just copying.
-//
-// Items in sets need to be hashable and comparable. This means they either need
-// to be some real numeric type (int, float32, etc...), string, []byte, or
-// implement the Setable interface.
-type Set struct {
+// The number of bits of a value's hash used to pick a slot at each level of a
+// Set
+const hashBits = 5
 
-	// The value being held
-	val interface{}
+// The depth at which a value's hash has been used up. Every value held under a
+// node at this depth has the same hash, so such nodes are collision nodes, see
+// setNode.
+const hashLevels = (32 + hashBits - 1) / hashBits
 
-	// Whether or not the held value has been set yet. Needed because the value
-	// could be nil
-	full bool
+// Returns the index of the slot which a value with the given hash belongs in,
+// for a node at the given depth
+func hashIndex(h, depth uint32) uint32 {
+	return (h >> (depth * hashBits)) & (ARITY - 1)
+}
 
-	// Slice of kids of this node. Could be an empty slice
-	kids []*Set
+// A setEntry is a single slot in a setNode. It holds either a value along with
+// that value's hash, or another setNode.
+type setEntry struct {
+	val  interface{}
+	node *setNode
+	h    uint32
+}
 
-	// Number of values in this Set.
+// A setNode is a single node in a Set's hash tree, which is laid out as a hash
+// array mapped trie. Of the ARITY slots a node has, bitmap has a bit set for
+// each one which is occupied, and entries holds only those slots, in order. A
+// node at hashLevels depth is instead a collision node: its bitmap is zero, and
+// its entries are all values with the same hash, in no particular order.
+//
+// The tree is kept canonical: every node other than the root holds at least
+// two values between it and its descendants, otherwise its single value is
+// held directly by its parent. So the shape of a Set depends only on the values
+// in it, and not on the order they were added or removed in.
+//
+// A node whose edit is set is owned by the transient with that editToken, and
+// may be changed in place by it, see TransientSet.
+type setNode struct {
+	bitmap  uint32
+	entries []setEntry
+	edit    *editToken
+
+	// The number of values held by the node and its descendants
 	size uint64
 }
 
-// NewSet returns a new Set of the given elements (or no elements, for an empty
-// set)
-func NewSet(vals ...interface{}) *Set {
-	if len(vals) == 0 {
-		return nil
+// Returns the number of values held by the entry
+func (e setEntry) size() uint64 {
+	if e.node != nil {
+		return e.node.size
 	}
-	set := new(Set)
-	for i := range vals {
-		set.setValDirty(vals[i], 0)
+	return 1
+}
+
+// Returns whether the two entries, neither of which may be a node, hold equal
+// values
+func (e setEntry) sameVal(e2 setEntry) bool {
+	return e.h == e2.h && equal(e.val, e2.val)
+}
+
+// Returns the index in entries of the slot with the given bit
+func (n *setNode) index(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// Returns the entry in the slot with the given bit, or false if it's empty
+func (n *setNode) slot(bit uint32) (setEntry, bool) {
+	if n.bitmap&bit == 0 {
+		return setEntry{}, false
 	}
-	set.size = uint64(len(vals))
-	return set
+	return n.entries[n.index(bit)], true
 }
 
-// Hash implements the Hash method for the Setable interface
-func (set *Set) Hash(i uint32) uint32 {
-	sum := uint32(0)
-	s := Seq(set)
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); !ok {
-			return sum
+// Returns the entry which should hold this node in its parent. This is the
+// node's value, if it only holds the one, and the node itself otherwise.
+func (n *setNode) entry() setEntry {
+	if len(n.entries) == 1 && n.entries[0].node == nil {
+		return n.entries[0]
+	}
+	return setEntry{node: n}
+}
+
+// Returns whether this node may be changed in place by the given edit. Nothing
+// may change a node in place when edit is nil.
+func (n *setNode) owned(edit *editToken) bool {
+	return edit != nil && n.edit == edit
+}
+
+// Returns a version of this node which may be changed in place by the given
+// edit: the node itself if it's already owned by edit, otherwise a copy of it,
+// including allocating and copying the entries slice
+func (n *setNode) editable(edit *editToken) *setNode {
+	if n.owned(edit) {
+		return n
+	}
+	entries := make([]setEntry, len(n.entries))
+	copy(entries, n.entries)
+	return &setNode{bitmap: n.bitmap, entries: entries, edit: edit, size: n.size}
+}
+
+// Returns a version of this node, see editable, with the given entry inserted
+// at index i, and bit set in its bitmap
+func (n *setNode) insert(i int, bit uint32, e setEntry, edit *editToken) *setNode {
+	if n.owned(edit) {
+		n.entries = append(n.entries, setEntry{})
+		copy(n.entries[i+1:], n.entries[i:])
+		n.entries[i] = e
+		n.bitmap |= bit
+		n.size += e.size()
+		return n
+	}
+	entries := make([]setEntry, len(n.entries)+1)
+	copy(entries, n.entries[:i])
+	entries[i] = e
+	copy(entries[i+1:], n.entries[i:])
+	size := n.size + e.size()
+	return &setNode{bitmap: n.bitmap | bit, entries: entries, edit: edit, size: size}
+}
+
+// Returns a version of this node, see editable, with the entry at index i
+// removed, and bit unset in its bitmap
+func (n *setNode) remove(i int, bit uint32, edit *editToken) *setNode {
+	if n.owned(edit) {
+		last := len(n.entries) - 1
+		n.size -= n.entries[i].size()
+		copy(n.entries[i:], n.entries[i+1:])
+		n.entries[last] = setEntry{}
+		n.entries = n.entries[:last]
+		n.bitmap &^= bit
+		return n
+	}
+	entries := make([]setEntry, len(n.entries)-1)
+	copy(entries, n.entries[:i])
+	copy(entries[i:], n.entries[i+1:])
+	size := n.size - n.entries[i].size()
+	return &setNode{bitmap: n.bitmap &^ bit, entries: entries, edit: edit, size: size}
+}
+
+// Returns a new node, for the given depth, holding the values of the two given
+// entries, which must have different values
+func newSetNode2(e1, e2 setEntry, depth uint32, edit *editToken) *setNode {
+	n := &setNode{edit: edit, size: 2}
+	if depth >= hashLevels {
+		n.entries = []setEntry{e1, e2}
+		return n
+	}
+
+	i1, i2 := hashIndex(e1.h, depth), hashIndex(e2.h, depth)
+	if i1 == i2 {
+		n.bitmap = 1 << i1
+		n.entries = []setEntry{{node: newSetNode2(e1, e2, depth+1, edit)}}
+		return n
+	} else if i1 > i2 {
+		e1, e2, i1, i2 = e2, e1, i2, i1
+	}
+	n.bitmap = 1<<i1 | 1<<i2
+	n.entries = []setEntry{e1, e2}
+	return n
+}
+
+// Returns the index in a collision node's entries of the given value, or -1
+func (n *setNode) collIndex(val interface{}) int {
+	for i := range n.entries {
+		if equal(n.entries[i].val, val) {
+			return i
 		}
-		sum += hash(el, i)
 	}
+	return -1
 }
 
-// Equal implements the Equal method for the Comparable and Setable interfaces
-func (set *Set) Equal(v interface{}) bool {
-	set2, ok := v.(*Set)
+// Returns a version of the node, see editable, with the given entry's value
+// set in it, and whether or not the value is new to the node
+func (n *setNode) set(e setEntry, depth uint32, edit *editToken) (*setNode, bool) {
+	if depth >= hashLevels {
+		if i := n.collIndex(e.val); i >= 0 {
+			cn := n.editable(edit)
+			cn.entries[i] = e
+			return cn, false
+		}
+		return n.insert(len(n.entries), 0, e, edit), true
+	}
+
+	bit := uint32(1) << hashIndex(e.h, depth)
+	i := n.index(bit)
+	if n.bitmap&bit == 0 {
+		return n.insert(i, bit, e, edit), true
+	}
+
+	cur := n.entries[i]
+	if cur.node != nil {
+		kid, ok := cur.node.set(e, depth+1, edit)
+		cn := n.editable(edit)
+		cn.entries[i] = setEntry{node: kid}
+		if ok {
+			cn.size++
+		}
+		return cn, ok
+	}
+
+	cn := n.editable(edit)
+	if cur.sameVal(e) {
+		cn.entries[i] = e
+		return cn, false
+	}
+	cn.entries[i] = setEntry{node: newSetNode2(cur, e, depth+1, edit)}
+	cn.size++
+	return cn, true
+}
+
+// Returns a version of the node, see editable, with the given value, whose
+// hash is h, removed from it, and whether or not it was there. The returned
+// node may only hold a single value, in which case its parent should hold that
+// value directly, see entry.
+func (n *setNode) del(val interface{}, h, depth uint32, edit *editToken) (*setNode, bool) {
+	if depth >= hashLevels {
+		if i := n.collIndex(val); i >= 0 {
+			return n.remove(i, 0, edit), true
+		}
+		return n, false
+	}
+
+	bit := uint32(1) << hashIndex(h, depth)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+
+	i := n.index(bit)
+	cur := n.entries[i]
+	if cur.node == nil {
+		if cur.h != h || !equal(cur.val, val) {
+			return n, false
+		}
+		return n.remove(i, bit, edit), true
+	}
+
+	kid, ok := cur.node.del(val, h, depth+1, edit)
 	if !ok {
-		return false
+		return n, false
 	}
-	if set.Size() != set2.Size() {
-		return false
+	cn := n.editable(edit)
+	cn.entries[i] = kid.entry()
+	cn.size--
+	return cn, true
+}
+
+// setAlterFn is given the value in a node which is equal to the one being
+// altered, or nil and false if there isn't one. It returns the value which
+// should replace it, which must be equal to the one being altered, or false if
+// there should be none.
+type setAlterFn func(cur interface{}, ok bool) (interface{}, bool)
+
+// Returns a copy of the node with the value equal to the given one, whose hash
+// is h, replaced by the result of fn, all in one walk down the node. Also
+// returns the change in the number of values held by the node, which is -1, 0
+// or 1. Like del, the returned node may only hold a single value. If fn
+// declines to add a value which isn't there the node is returned as-is.
+func (n *setNode) alter(val interface{}, h, depth uint32, fn setAlterFn) (*setNode, int) {
+	if depth >= hashLevels {
+		i := n.collIndex(val)
+		if i < 0 {
+			if nval, ok := fn(nil, false); ok {
+				return n.insert(len(n.entries), 0, setEntry{val: nval, h: h}, nil), 1
+			}
+			return n, 0
+		} else if nval, ok := fn(n.entries[i].val, true); ok {
+			cn := n.editable(nil)
+			cn.entries[i] = setEntry{val: nval, h: h}
+			return cn, 0
+		}
+		return n.remove(i, 0, nil), -1
 	}
 
-	sset := Seq(set)
-	var el interface{}
-	for {
-		el, sset, ok = sset.FirstRest()
+	bit := uint32(1) << hashIndex(h, depth)
+	i := n.index(bit)
+	if n.bitmap&bit == 0 {
+		if nval, ok := fn(nil, false); ok {
+			return n.insert(i, bit, setEntry{val: nval, h: h}, nil), 1
+		}
+		return n, 0
+	}
+
+	cur := n.entries[i]
+	if cur.node != nil {
+		kid, delta := cur.node.alter(val, h, depth+1, fn)
+		if kid == cur.node {
+			return n, 0
+		}
+		cn := n.editable(nil)
+		cn.entries[i] = kid.entry()
+		cn.size += uint64(delta) // wraps around when delta is -1
+		return cn, delta
+	}
+
+	if cur.h != h || !equal(cur.val, val) {
+		nval, ok := fn(nil, false)
 		if !ok {
-			return true
-		} else if _, ok = set2.GetVal(el); !ok {
-			return false
+			return n, 0
+		}
+		cn := n.editable(nil)
+		cn.entries[i] = setEntry{node: newSetNode2(cur, setEntry{val: nval, h: h}, depth+1, nil)}
+		cn.size++
+		return cn, 1
+	} else if nval, ok := fn(cur.val, true); ok {
+		cn := n.editable(nil)
+		cn.entries[i] = setEntry{val: nval, h: h}
+		return cn, 0
+	}
+	return n.remove(i, bit, nil), -1
+}
+
+// Returns a setAlterFn which sets val, or if merge is given and there's a
+// value already there sets the result of merging that with val. If first is
+// true the value already there is passed to merge first, otherwise val is.
+func setMergeAlterFn(val interface{}, merge func(v1, v2 interface{}) interface{}, first bool) setAlterFn {
+	return func(cur interface{}, ok bool) (interface{}, bool) {
+		if !ok || merge == nil {
+			return val, true
+		} else if first {
+			return merge(cur, val), true
 		}
+		return merge(val, cur), true
 	}
 }
 
-// Methods marked as "dirty" operate on the node in place, and potentially
-// change it or its children.
+// Returns the value for the given value, whose hash is h, from the node, and
+// whether or not it was there
+func (n *setNode) get(val interface{}, h, depth uint32) (interface{}, bool) {
+	if depth >= hashLevels {
+		if i := n.collIndex(val); i >= 0 {
+			return n.entries[i].val, true
+		}
+		return nil, false
+	}
+
+	bit := uint32(1) << hashIndex(h, depth)
+	if n.bitmap&bit == 0 {
+		return nil, false
+	}
+
+	cur := n.entries[n.index(bit)]
+	if cur.node != nil {
+		return cur.node.get(val, h, depth+1)
+	} else if cur.h == h && equal(cur.val, val) {
+		return cur.val, true
+	}
+	return nil, false
+}
+
+// Returns the node's first value, in the same order that setCursor yields them,
+// and a copy of the node with that value removed from it. Like del, the
+// returned node may only hold a single value.
+func (n *setNode) firstRest() (interface{}, *setNode) {
+	first := n.entries[0]
+	if first.node == nil {
+		// The lowest set bit is the first slot's, collision nodes have none
+		return first.val, n.remove(0, n.bitmap&-n.bitmap, nil)
+	}
+
+	val, kid := first.node.firstRest()
+	cn := n.editable(nil)
+	cn.entries[0] = kid.entry()
+	cn.size--
+	return val, cn
+}
+
+// setMerge builds the node resulting from merging two nodes, n1 and n2, one
+// slot at a time in bit order. It tracks whether the result is the same as
+// either of them, in which case that node is reused rather than a new one made.
+type setMerge struct {
+	n1, n2       *setNode
+	bitmap       uint32
+	entries      []setEntry
+	same1, same2 bool
+}
 
-// Dirty. Tries to set the val on this Set node, or initialize the kids slice if
-// it can't. Returns whether or not the value was set and whether or not it was
-// already set.
-func (set *Set) shallowTrySetOrInit(val interface{}) (bool, bool) {
-	if !set.full {
-		set.val = val
-		set.full = true
-		return true, false
-	} else if equal(set.val, val) {
-		set.val = val
-		set.full = true
-		return true, true
-	} else if set.kids == nil {
-		set.kids = make([]*Set, ARITY)
+func newSetMerge(n1, n2 *setNode, bitmap uint32) *setMerge {
+	return &setMerge{
+		n1:      n1,
+		n2:      n2,
+		entries: make([]setEntry, 0, bits.OnesCount32(bitmap)),
+		same1:   true,
+		same2:   true,
 	}
-	return false, false
 }
 
-// dirty (obviously). Sets a value on this node in place. Only used during
-// initialization.
-func (set *Set) setValDirty(val interface{}, i uint32) {
-	if ok, _ := set.shallowTrySetOrInit(val); ok {
+// Adds the entry to the slot with the given bit. from1 and from2 say whether
+// it's the entry already in that slot in n1 or n2, respectively.
+func (m *setMerge) add(bit uint32, e setEntry, from1, from2 bool) {
+	m.bitmap |= bit
+	m.entries = append(m.entries, e)
+	m.same1 = m.same1 && from1
+	m.same2 = m.same2 && from2
+}
+
+// Adds the node resulting from merging the slot with the given bit. A nil or
+// empty node leaves the slot empty.
+func (m *setMerge) addNode(bit uint32, kid *setNode) {
+	if kid == nil || len(kid.entries) == 0 {
 		return
 	}
+	e1, _ := m.n1.slot(bit)
+	e2, _ := m.n2.slot(bit)
+	e := kid.entry()
+	m.add(bit, e, e.node != nil && e.node == e1.node, e.node != nil && e.node == e2.node)
+}
+
+// Returns the merged node, which is nil if it's empty
+func (m *setMerge) node() *setNode {
+	if m.same1 && m.bitmap == m.n1.bitmap {
+		return m.n1
+	} else if m.same2 && m.bitmap == m.n2.bitmap {
+		return m.n2
+	} else if len(m.entries) == 0 {
+		return nil
+	}
+	n := &setNode{bitmap: m.bitmap, entries: m.entries}
+	for _, e := range m.entries {
+		n.size += e.size()
+	}
+	return n
+}
+
+// Returns a collision node, or nil if it's empty
+func nonEmpty(n *setNode) *setNode {
+	if len(n.entries) == 0 {
+		return nil
+	}
+	return n
+}
+
+// Returns a copy of the node with the given entry's value removed from it if
+// it was there, or added to it if it wasn't
+func (n *setNode) toggle(e setEntry, depth uint32) *setNode {
+	if cn, ok := n.del(e.val, e.h, depth, nil); ok {
+		return cn
+	}
+	cn, _ := n.set(e, depth, nil)
+	return cn
+}
+
+// Returns a node holding the values of both nodes. Where both hold a value the
+// one in n2 is kept, unless merge is given, in which case the result of calling
+// it with n's value and n2's value is kept instead. Subtrees which n and n2
+// share are kept as-is, unless merge is given, since it must still be called
+// on every value in them.
+func (n *setNode) union(n2 *setNode, depth uint32, merge func(v1, v2 interface{}) interface{}) *setNode {
+	if n == n2 && merge == nil {
+		return n
+	} else if depth >= hashLevels {
+		for _, e := range n2.entries {
+			n, _ = n.alter(e.val, e.h, depth, setMergeAlterFn(e.val, merge, true))
+		}
+		return n
+	}
+
+	m := newSetMerge(n, n2, n.bitmap|n2.bitmap)
+	for bm := n.bitmap | n2.bitmap; bm != 0; bm &= bm - 1 {
+		bit := bm & -bm
+		e1, ok1 := n.slot(bit)
+		e2, ok2 := n2.slot(bit)
+		switch {
+		case !ok2:
+			m.add(bit, e1, true, false)
+		case !ok1:
+			m.add(bit, e2, false, true)
+		case e1.node != nil && e2.node != nil:
+			m.addNode(bit, e1.node.union(e2.node, depth+1, merge))
+		case e1.node != nil:
+			kid, _ := e1.node.alter(e2.val, e2.h, depth+1, setMergeAlterFn(e2.val, merge, true))
+			m.addNode(bit, kid)
+		case e2.node != nil:
+			if _, ok := e2.node.get(e1.val, e1.h, depth+1); ok && merge == nil {
+				m.add(bit, e2, false, true)
+			} else {
+				// If e1's value isn't in e2 it's added, otherwise it's merged
+				// with the one that is
+				kid, _ := e2.node.alter(e1.val, e1.h, depth+1, setMergeAlterFn(e1.val, merge, false))
+				m.addNode(bit, kid)
+			}
+		case e1.sameVal(e2) && merge == nil:
+			m.add(bit, e2, false, true)
+		case e1.sameVal(e2):
+			m.add(bit, setEntry{val: merge(e1.val, e2.val), h: e2.h}, false, false)
+		default:
+			m.add(bit, setEntry{node: newSetNode2(e1, e2, depth+1, nil)}, false, false)
+		}
+	}
+	return m.node()
+}
+
+// Returns a node holding the values of n2 which are also in n, or nil if there
+// are none
+func (n *setNode) intersection(n2 *setNode, depth uint32) *setNode {
+	if n == n2 {
+		return n
+	} else if depth >= hashLevels {
+		in := n2
+		for _, e := range n2.entries {
+			if n.collIndex(e.val) < 0 {
+				in, _ = in.del(e.val, e.h, depth, nil)
+			}
+		}
+		return nonEmpty(in)
+	}
+
+	m := newSetMerge(n, n2, n.bitmap&n2.bitmap)
+	for bm := n.bitmap & n2.bitmap; bm != 0; bm &= bm - 1 {
+		bit := bm & -bm
+		e1, _ := n.slot(bit)
+		e2, _ := n2.slot(bit)
+		switch {
+		case e1.node != nil && e2.node != nil:
+			m.addNode(bit, e1.node.intersection(e2.node, depth+1))
+		case e1.node != nil:
+			if _, ok := e1.node.get(e2.val, e2.h, depth+1); ok {
+				m.add(bit, e2, false, true)
+			}
+		case e2.node != nil:
+			if val, ok := e2.node.get(e1.val, e1.h, depth+1); ok {
+				m.add(bit, setEntry{val: val, h: e1.h}, false, false)
+			}
+		case e1.sameVal(e2):
+			m.add(bit, e2, false, true)
+		}
+	}
+	return m.node()
+}
+
+// Returns a node holding the values of n which aren't in n2, or nil if there
+// are none
+func (n *setNode) difference(n2 *setNode, depth uint32) *setNode {
+	if n == n2 {
+		return nil
+	} else if depth >= hashLevels {
+		for _, e := range n2.entries {
+			n, _ = n.del(e.val, e.h, depth, nil)
+		}
+		return nonEmpty(n)
+	}
+
+	m := newSetMerge(n, n2, n.bitmap)
+	for bm := n.bitmap; bm != 0; bm &= bm - 1 {
+		bit := bm & -bm
+		e1, _ := n.slot(bit)
+		e2, ok2 := n2.slot(bit)
+		switch {
+		case !ok2:
+			m.add(bit, e1, true, false)
+		case e1.node != nil && e2.node != nil:
+			m.addNode(bit, e1.node.difference(e2.node, depth+1))
+		case e1.node != nil:
+			kid, _ := e1.node.del(e2.val, e2.h, depth+1, nil)
+			m.addNode(bit, kid)
+		case e2.node != nil:
+			if _, ok := e2.node.get(e1.val, e1.h, depth+1); !ok {
+				m.add(bit, e1, true, false)
+			}
+		case !e1.sameVal(e2):
+			m.add(bit, e1, true, false)
+		}
+	}
+	return m.node()
+}
+
+// Returns a node holding the values which are in only one of n and n2, or nil
+// if there are none
+func (n *setNode) symDifference(n2 *setNode, depth uint32) *setNode {
+	if n == n2 {
+		return nil
+	} else if depth >= hashLevels {
+		for _, e := range n2.entries {
+			n = n.toggle(e, depth)
+		}
+		return nonEmpty(n)
+	}
 
-	h := hash(val, i)
-	if kid := set.kids[h]; kid != nil {
-		kid.setValDirty(val, i+1)
-	} else {
-		set.kids[h] = NewSet(val)
+	m := newSetMerge(n, n2, n.bitmap|n2.bitmap)
+	for bm := n.bitmap | n2.bitmap; bm != 0; bm &= bm - 1 {
+		bit := bm & -bm
+		e1, ok1 := n.slot(bit)
+		e2, ok2 := n2.slot(bit)
+		switch {
+		case !ok2:
+			m.add(bit, e1, true, false)
+		case !ok1:
+			m.add(bit, e2, false, true)
+		case e1.node != nil && e2.node != nil:
+			m.addNode(bit, e1.node.symDifference(e2.node, depth+1))
+		case e1.node != nil:
+			m.addNode(bit, e1.node.toggle(e2, depth+1))
+		case e2.node != nil:
+			m.addNode(bit, e2.node.toggle(e1, depth+1))
+		case !e1.sameVal(e2):
+			m.add(bit, setEntry{node: newSetNode2(e1, e2, depth+1, nil)}, false, false)
+		}
+	}
+	return m.node()
+}
+
+// Returns whether every value in n is also in n2
+func (n *setNode) subset(n2 *setNode, depth uint32) bool {
+	if n == n2 {
+		return true
+	} else if n.size > n2.size {
+		return false
+	} else if depth >= hashLevels {
+		for _, e := range n.entries {
+			if n2.collIndex(e.val) < 0 {
+				return false
+			}
+		}
+		return true
+	} else if n.bitmap&^n2.bitmap != 0 {
+		return false
+	}
+
+	for bm := n.bitmap; bm != 0; bm &= bm - 1 {
+		bit := bm & -bm
+		e1, _ := n.slot(bit)
+		e2, _ := n2.slot(bit)
+		switch {
+		case e1.node != nil && e2.node != nil:
+			if !e1.node.subset(e2.node, depth+1) {
+				return false
+			}
+		case e1.node != nil:
+			// A non-root node always holds more than one value
+			return false
+		case e2.node != nil:
+			if _, ok := e2.node.get(e1.val, e1.h, depth+1); !ok {
+				return false
+			}
+		case !e1.sameVal(e2):
+			return false
+		}
 	}
+	return true
 }
 
-// Returns a copy of this set node, including allocating and copying the kids
-// slice.
-func (set *Set) clone() *Set {
-	var newkids []*Set
-	if set.kids != nil {
-		newkids = make([]*Set, ARITY)
-		copy(newkids, set.kids)
+// Returns whether n and n2 have no values in common
+func (n *setNode) disjoint(n2 *setNode, depth uint32) bool {
+	if n == n2 {
+		return len(n.entries) == 0
+	} else if depth >= hashLevels {
+		for _, e := range n.entries {
+			if n2.collIndex(e.val) >= 0 {
+				return false
+			}
+		}
+		return true
 	}
-	cs := &Set{
-		val:  set.val,
-		full: set.full,
-		kids: newkids,
-		size: set.size,
+
+	for bm := n.bitmap & n2.bitmap; bm != 0; bm &= bm - 1 {
+		bit := bm & -bm
+		e1, _ := n.slot(bit)
+		e2, _ := n2.slot(bit)
+		switch {
+		case e1.node != nil && e2.node != nil:
+			if !e1.node.disjoint(e2.node, depth+1) {
+				return false
+			}
+		case e1.node != nil:
+			if _, ok := e1.node.get(e2.val, e2.h, depth+1); ok {
+				return false
+			}
+		case e2.node != nil:
+			if _, ok := e2.node.get(e1.val, e1.h, depth+1); ok {
+				return false
+			}
+		case e1.sameVal(e2):
+			return false
+		}
 	}
-	return cs
+	return true
+}
+
+// A Set is an implementation of Seq in the form of a persistant hash-tree. All
+// public operations on it return a new, immutable form of the modified
+// variable, leaving the old one intact. Immutability is implemented through
+// node sharing, so operations aren't actually copying the entire hash-tree
+// everytime, only the nodes which change, making the implementation very
+// efficient compared to just copying.
+//
+// Items in sets need to be hashable and comparable. This means they either need
+// to implement the Setable interface, be []byte, or be comparable using ==
+// (numbers, strings, bools, nil, pointers, channels, and structs and arrays made
+// up of those). As with go's maps, NaN is never equal to itself, so each NaN
+// put in a Set is a separate element.
+//
+// A nil *Set is an empty Set, and all operations which leave a Set empty
+// return nil. A zero Set, which is what UnmarshalJSON makes from an empty JSON
+// array, is empty too.
+type Set struct {
+
+	// The root node of the hash-tree. Only nil in a zero Set, and never empty.
+	// Its size is the number of values in the Set.
+	root *setNode
 }
 
-// The actual implementation of SetVal, because we need to pass i down the stack
-func (set *Set) internalSetVal(val interface{}, i uint32) (*Set, bool) {
+// Returns the Set's root node, or nil if the Set is empty
+func (set *Set) rootNode() *setNode {
 	if set == nil {
-		return NewSet(val), true
+		return nil
 	}
-	cset := set.clone()
-	if ok, prev := cset.shallowTrySetOrInit(val); ok {
-		return cset, !prev
+	return set.root
+}
+
+// NewSet returns a new Set of the given elements (or no elements, for an empty
+// set)
+func NewSet(vals ...interface{}) *Set {
+	set, err := TryNewSet(vals...)
+	if err != nil {
+		panic(err)
 	}
+	return set
+}
 
-	h := hash(val, i)
-	newkid, ok := cset.kids[h].internalSetVal(val, i+1)
-	cset.kids[h] = newkid
-	return cset, ok
+// TryNewSet is like NewSet, but returns an ErrUnhashable if any of the given
+// values can't be hashed, rather than panicking
+func TryNewSet(vals ...interface{}) (*Set, error) {
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	t := (*Set)(nil).Transient()
+	for i := range vals {
+		if _, err := t.TrySetVal(vals[i]); err != nil {
+			return nil, err
+		}
+	}
+	return t.Persistent(), nil
+}
+
+// Hash implements the Hash method for the Setable interface
+func (set *Set) Hash(i uint32) uint32 {
+	sum := uint32(0)
+	set.each(func(el interface{}) bool {
+		sum += hash(el, i)
+		return true
+	})
+	return sum
+}
+
+// Equal implements the Equal method for the Comparable and Setable interfaces
+func (set *Set) Equal(v interface{}) bool {
+	set2, ok := v.(*Set)
+	if !ok {
+		return false
+	}
+	if set.Size() != set2.Size() {
+		return false
+	}
+
+	eq := true
+	set.each(func(el interface{}) bool {
+		_, eq = set2.GetVal(el)
+		return eq
+	})
+	return eq
 }
 
 // SetVal returns a new Set with the given value added to it. Also returns
 // whether or not this is the first time setting this value (false if it was
 // already there and was overwritten). Completes in O(log(N)) time.
 func (set *Set) SetVal(val interface{}) (*Set, bool) {
-	nset, ok := set.internalSetVal(val, 0)
-	if ok {
-		if set == nil {
-			nset.size = 1
-		} else {
-			nset.size = set.size + 1
-		}
+	nset, ok, err := set.TrySetVal(val)
+	if err != nil {
+		panic(err)
 	}
 	return nset, ok
 }
 
-// The actual implementation of DelVal, because we need to pass i down the stack
-func (set *Set) internalDelVal(val interface{}, i uint32) (*Set, bool) {
-	if set == nil {
-		return nil, false
-	} else if set.full && equal(val, set.val) {
-		cset := set.clone()
-		cset.val = nil
-		cset.full = false
-		return cset, true
-	} else if set.kids == nil {
-		return set, false
+// TrySetVal is like SetVal, but returns an ErrUnhashable, and the Set
+// unchanged, if the value can't be hashed, rather than panicking
+func (set *Set) TrySetVal(val interface{}) (*Set, bool, error) {
+	h, err := tryHash(val)
+	if err != nil {
+		return set, false, err
 	}
 
-	h := hash(val, i)
-	if newkid, ok := set.kids[h].internalDelVal(val, i+1); ok {
-		cset := set.clone()
-		cset.kids[h] = newkid
-		return cset, true
+	e := setEntry{val: val, h: h}
+	if set.rootNode() == nil {
+		root := &setNode{bitmap: 1 << hashIndex(h, 0), entries: []setEntry{e}, size: 1}
+		return &Set{root}, true, nil
 	}
-	return set, false
+
+	root, ok := set.root.set(e, 0, nil)
+	return &Set{root}, ok, nil
 }
 
 // DelVal returns a new Set with the given value removed from it and whether or
 // not the value was actually removed. Completes in O(log(N)) time.
 func (set *Set) DelVal(val interface{}) (*Set, bool) {
-	nset, ok := set.internalDelVal(val, 0)
-	if ok && nset != nil {
-		nset.size--
+	nset, ok, err := set.TryDelVal(val)
+	if err != nil {
+		panic(err)
 	}
 	return nset, ok
 }
 
-// The actual implementation of GetVal, because we need to pass i down the stack
-func (set *Set) internalGetVal(val interface{}, i uint32) (interface{}, bool) {
-	if set == nil {
-		return nil, false
-	} else if set.full && equal(val, set.val) {
-		return set.val, true
-	} else if set.kids == nil {
-		return nil, false
+// TryDelVal is like DelVal, but returns an ErrUnhashable, and the Set
+// unchanged, if the value can't be hashed, rather than panicking
+func (set *Set) TryDelVal(val interface{}) (*Set, bool, error) {
+	h, err := tryHash(val)
+	if err != nil || set.rootNode() == nil {
+		return set, false, err
 	}
 
-	h := hash(val, i)
-	return set.kids[h].internalGetVal(val, i+1)
+	root, ok := set.root.del(val, h, 0, nil)
+	if !ok {
+		return set, false, nil
+	} else if len(root.entries) == 0 {
+		return nil, true, nil
+	}
+	return &Set{root}, true, nil
 }
 
 // GetVal returns a value from the Set, along with  a boolean indiciating
 // whether or not the value was found. Completes in O(log(N)) time.
 func (set *Set) GetVal(val interface{}) (interface{}, bool) {
-	return set.internalGetVal(val, 0)
+	el, ok, err := set.TryGetVal(val)
+	if err != nil {
+		panic(err)
+	}
+	return el, ok
 }
 
-// Actual implementation of FirstRest. Because we need it to return a *Set
-// instead of Seq for one case.
-func (set *Set) internalFirstRest() (interface{}, *Set, bool) {
-	if set == nil {
-		return nil, nil, false
-	}
-
-	if set.kids != nil {
-		var el interface{}
-		var rest *Set
-		var ok bool
-		for i := range set.kids {
-			if el, rest, ok = set.kids[i].internalFirstRest(); ok {
-				cset := set.clone()
-				cset.kids[i] = rest
-				return el, cset, true
-			}
-		}
+// TryGetVal is like GetVal, but returns an ErrUnhashable if the value can't be
+// hashed, rather than panicking
+func (set *Set) TryGetVal(val interface{}) (interface{}, bool, error) {
+	h, err := tryHash(val)
+	if err != nil || set.rootNode() == nil {
+		return nil, false, err
 	}
+	el, ok := set.root.get(val, h, 0)
+	return el, ok, nil
+}
 
-	// We're not nil, but we don't have a value and no kids had values. We might
-	// as well be nil.
-	if !set.full {
-		return nil, nil, false
+// FirstRest is an implementation of FirstRest for Seq interface. Completes in
+// O(log(N)) time, but since each call copies the nodes along the path to the
+// first value walking a whole Set this way is costly; range over Values
+// instead when the rest isn't needed.
+func (set *Set) FirstRest() (interface{}, Seq, bool) {
+	if set.rootNode() == nil {
+		return nil, set, false
 	}
 
-	return set.val, nil, true
+	el, root := set.root.firstRest()
+	return el, setFromRoot(root), true
 }
 
-// FirstRest is an implementation of FirstRest for Seq interface. Completes in
-// O(log(N)) time.
-func (set *Set) FirstRest() (interface{}, Seq, bool) {
-	el, restSet, ok := set.internalFirstRest()
-	if ok && restSet != nil {
-		restSet.size--
+// setCursor walks the nodes of a Set depth-first without copying any of them,
+// yielding values in the same order repeated calls to FirstRest would. A Set is
+// never deeper than hashLevels, so the cursor never needs to allocate.
+type setCursor struct {
+	stack [hashLevels + 1]setCursorFrame
+	depth int
+}
+
+type setCursorFrame struct {
+	node *setNode
+	i    int
+}
+
+func newSetCursor(set *Set) setCursor {
+	var c setCursor
+	if root := set.rootNode(); root != nil {
+		c.stack[0].node = root
+		c.depth = 1
+	}
+	return c
+}
+
+// next returns the next value in the Set, or false if there are none left
+func (c *setCursor) next() (interface{}, bool) {
+	for c.depth > 0 {
+		top := &c.stack[c.depth-1]
+		if top.i >= len(top.node.entries) {
+			c.depth--
+			continue
+		}
+
+		e := top.node.entries[top.i]
+		top.i++
+		if e.node == nil {
+			return e.val, true
+		}
+		c.stack[c.depth] = setCursorFrame{node: e.node}
+		c.depth++
+	}
+	return nil, false
+}
+
+// Calls fn on each value in the Set, until fn returns false
+func (set *Set) each(fn func(interface{}) bool) {
+	c := newSetCursor(set)
+	for el, ok := c.next(); ok && fn(el); el, ok = c.next() {
 	}
-	return el, Seq(restSet), ok
 }
 
 // Implementation of String for Stringer interface
@@ -362,94 +1153,144 @@ func (set *Set) String() string {
 
 // Size returns the number of elements in the Set. Completes in O(1) time.
 func (set *Set) Size() uint64 {
-	if set == nil {
+	if set.rootNode() == nil {
 		return 0
 	}
-	return set.size
+	return set.root.size
+}
+
+// Returns a Set with the given root node, or nil if it's empty
+func setFromRoot(root *setNode) *Set {
+	if root == nil || len(root.entries) == 0 {
+		return nil
+	}
+	return &Set{root}
 }
 
 // Union returns a Set with all of the elements of the original Set along with
 // everything in the given Seq. If an element is present in both the Set and the
 // Seq, the element in the Seq overwrites. Completes in O(M*log(N)), with M
 // being the number of elements in the Seq and N the number of elements in the
-// Set
+// Set.
+//
+// If the Seq is a Set the two hash-trees are merged node by node instead, and
+// any subtree the two have in common is shared with the result rather than
+// copied. This completes in at most O(N+M) time, and in much less when the two
+// are versions of the same Set, since only the parts where they differ are
+// visited.
 func (set *Set) Union(s Seq) *Set {
-	if set == nil {
+	if set.rootNode() == nil {
 		return ToSet(s)
+	} else if set2, ok := s.(*Set); ok {
+		if set2.rootNode() == nil {
+			return set
+		}
+		return setFromRoot(set.root.union(set2.root, 0, nil))
 	}
 
-	cset := set.clone()
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); !ok {
-			return cset
-		} else if cset, ok = cset.SetVal(el); ok {
-			cset.size++
-		}
+	cset := set
+	for el := range Values(s) {
+		cset, _ = cset.SetVal(el)
 	}
+	return cset
 }
 
 // Intersection returns a Set with all of the elements in Seq that are also in
 // Set. Completes in O(M*log(N)), with M being the number of elements in the Seq
-// and N the number of elements in the Set
+// and N the number of elements in the Set. If the Seq is a Set the two are
+// merged node by node, as with Union.
 func (set *Set) Intersection(s Seq) *Set {
-	if set == nil {
+	if set.rootNode() == nil {
 		return nil
+	} else if set2, ok := s.(*Set); ok {
+		if set2.rootNode() == nil {
+			return nil
+		}
+		return setFromRoot(set.root.intersection(set2.root, 0))
 	}
 
 	iset := NewSet()
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); !ok {
-			return iset
-		} else if _, ok = set.GetVal(el); ok {
+	for el := range Values(s) {
+		if _, ok := set.GetVal(el); ok {
 			iset, _ = iset.SetVal(el)
 		}
 	}
+	return iset
 }
 
 // Difference returns a Set of all elements in the original Set that aren't in
 // the Seq. Completes in O(M*log(N)), with M being the number of elements in the
-// Seq and N the number of elements in the Set
+// Seq and N the number of elements in the Set. If the Seq is a Set the two are
+// merged node by node, as with Union.
 func (set *Set) Difference(s Seq) *Set {
-	if set == nil {
+	if set.rootNode() == nil {
 		return nil
+	} else if set2, ok := s.(*Set); ok {
+		if set2.rootNode() == nil {
+			return set
+		}
+		return setFromRoot(set.root.difference(set2.root, 0))
 	}
 
-	cset := set.clone()
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); !ok {
-			return cset
-		}
+	cset := set
+	for el := range Values(s) {
 		cset, _ = cset.DelVal(el)
 	}
+	return cset
 }
 
 // SymDifference returns a Set of all elements that are either in the original
 // Set or the given Seq, but not in both. Completes in O(M*log(N)), with M being
-// the number of elements in the Seq and N the number of elements in the Set.
+// the number of elements in the Seq and N the number of elements in the Set. If
+// the Seq is a Set the two are merged node by node, as with Union.
 func (set *Set) SymDifference(s Seq) *Set {
-	if set == nil {
+	if set.rootNode() == nil {
 		return ToSet(s)
+	} else if set2, ok := s.(*Set); ok {
+		if set2.rootNode() == nil {
+			return set
+		}
+		return setFromRoot(set.root.symDifference(set2.root, 0))
 	}
 
-	cset := set.clone()
-	var cset2 *Set
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); !ok {
-			return cset
-		} else if cset2, ok = cset.DelVal(el); ok {
+	cset := set
+	for el := range Values(s) {
+		if cset2, ok := cset.DelVal(el); ok {
 			cset = cset2
 		} else {
 			cset, _ = cset.SetVal(el)
 		}
 	}
+	return cset
+}
+
+// IsSubset returns whether every element of the Set is also in the given Seq.
+// The Seq is converted using ToSet, and the two hash-trees are then compared
+// node by node, skipping any subtree they have in common.
+func (set *Set) IsSubset(s Seq) bool {
+	set2 := ToSet(s)
+	if set.rootNode() == nil {
+		return true
+	} else if set2.rootNode() == nil {
+		return false
+	}
+	return set.root.subset(set2.root, 0)
+}
+
+// IsSuperset returns whether every element of the given Seq is also in the Set.
+// It works the same way as IsSubset.
+func (set *Set) IsSuperset(s Seq) bool {
+	return ToSet(s).IsSubset(set)
+}
+
+// Disjoint returns whether the Set and the given Seq have no elements in
+// common. It works the same way as IsSubset.
+func (set *Set) Disjoint(s Seq) bool {
+	set2 := ToSet(s)
+	if set.rootNode() == nil || set2.rootNode() == nil {
+		return true
+	}
+	return set.root.disjoint(set2.root, 0)
 }
 
 // ToSet returns the elements in the Seq as a set. In general this completes in
@@ -465,3 +1306,15 @@ func ToSet(s Seq) *Set {
 	vals := ToSlice(s)
 	return NewSet(vals...)
 }
+
+// TryToSet is like ToSet, but returns an ErrUnhashable if any of the Seq's
+// elements can't be hashed, rather than panicking
+func TryToSet(s Seq) (*Set, error) {
+	if set, ok := s.(*Set); ok {
+		return set, nil
+	} else if hm, ok := s.(*HashMap); ok {
+		return hm.set, nil
+	}
+	vals := ToSlice(s)
+	return TryNewSet(vals...)
+}
@@ -1,6 +1,12 @@
 package seq
 
 import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
+	"reflect"
+	"runtime"
 	. "testing"
 
 	"github.com/stretchr/testify/assert"
@@ -157,6 +163,10 @@ func TestSetSize(t *T) {
 	assert.Equal(t, uint64(2), s.Size())
 	s, _ = s.SetVal(5)
 	assert.Equal(t, uint64(3), s.Size())
+
+	// Union with values both in and not in the Set
+	s = s.Union(NewList(5, 6))
+	assert.Equal(t, uint64(4), s.Size())
 }
 
 // Test that Union functions properly
@@ -280,3 +290,669 @@ func TestSetEqual(t *T) {
 	assert.Equal(t, true, s1.Equal(s2))
 	assert.Equal(t, true, s2.Equal(s1))
 }
+
+// Test that iterating over a Set with Values yields the same elements, in the
+// same order, as calling FirstRest repeatedly, including after deletes have
+// left empty nodes behind in the tree
+func TestSetValues(t *T) {
+	s := NewSet()
+	for i := 0; i < 500; i++ {
+		s, _ = s.SetVal(i)
+	}
+	for i := 0; i < 500; i += 3 {
+		s, _ = s.DelVal(i)
+	}
+
+	var expected []interface{}
+	var el interface{}
+	var ok bool
+	for cur := Seq(s); ; {
+		if el, cur, ok = cur.FirstRest(); !ok {
+			break
+		}
+		expected = append(expected, el)
+	}
+
+	var got []interface{}
+	for el := range Values(s) {
+		got = append(got, el)
+	}
+	assert.Equal(t, expected, got)
+	assert.Equal(t, expected, ToSlice(s))
+
+	var nilSet *Set
+	assert.Equal(t, []interface{}{}, ToSlice(nilSet))
+}
+
+func benchSet(n int) *Set {
+	s := NewSet()
+	for i := 0; i < n; i++ {
+		s, _ = s.SetVal(i)
+	}
+	return s
+}
+
+func BenchmarkSetToSlice(b *B) {
+	s := benchSet(1000)
+	b.ReportAllocs()
+	for b.Loop() {
+		ToSlice(s)
+	}
+}
+
+func BenchmarkSetEqual(b *B) {
+	s1, s2 := benchSet(1000), benchSet(1000)
+	b.ReportAllocs()
+	for b.Loop() {
+		s1.Equal(s2)
+	}
+}
+
+func BenchmarkSetUnion(b *B) {
+	s1, s2 := benchSet(1000), NewSet()
+	for i := 500; i < 1500; i++ {
+		s2, _ = s2.SetVal(i)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		s1.Union(s2)
+	}
+}
+
+func BenchmarkSetGetValString(b *B) {
+	keys := make([]string, 1000)
+	s := NewSet()
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+		s, _ = s.SetVal(keys[i])
+	}
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		s.GetVal(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkSetSetValInt(b *B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		benchSet(1000)
+	}
+}
+
+// collider is a Setable whose values all have the same hash
+type collider int
+
+func (c collider) Hash(uint32) uint32 { return 7 }
+
+func (c collider) Equal(v interface{}) bool {
+	c2, ok := v.(collider)
+	return ok && c == c2
+}
+
+// Returns the depth of the deepest node in the Set
+func setDepth(set *Set) int {
+	if set == nil {
+		return 0
+	}
+	var nodeDepth func(*setNode) int
+	nodeDepth = func(n *setNode) int {
+		max := 0
+		for _, e := range n.entries {
+			if e.node == nil {
+				continue
+			} else if d := nodeDepth(e.node); d > max {
+				max = d
+			}
+		}
+		return max + 1
+	}
+	return nodeDepth(set.root)
+}
+
+// Test that values whose hashes are all the same end up together in a
+// collision node, and can still be set, gotten and deleted individually
+func TestSetCollisions(t *T) {
+	s := NewSet()
+	for i := 0; i < 100; i++ {
+		s, _ = s.SetVal(collider(i))
+	}
+	assert.Equal(t, uint64(100), s.Size())
+	assert.Equal(t, hashLevels+1, setDepth(s))
+	assert.Len(t, ToSlice(s), 100)
+
+	s, ok := s.SetVal(collider(50))
+	assert.False(t, ok)
+	assert.Equal(t, uint64(100), s.Size())
+
+	for i := 0; i < 100; i += 2 {
+		s, ok = s.DelVal(collider(i))
+		assert.True(t, ok)
+	}
+	for i := 0; i < 100; i++ {
+		_, ok = s.GetVal(collider(i))
+		assert.Equal(t, i%2 == 1, ok)
+	}
+	assert.Equal(t, uint64(50), s.Size())
+	assert.Len(t, ToSlice(s), 50)
+
+	// The same number in different types hashes the same, but isn't equal
+	ones := []interface{}{1, int8(1), int64(1), uint(1), uint32(1)}
+	s = NewSet(ones...)
+	assertSeqContentsSet(t, ones, s)
+	for _, one := range ones {
+		_, ok = s.GetVal(one)
+		assert.True(t, ok)
+	}
+}
+
+// Test that values which share their low hash bits don't all end up in a
+// single chain
+func TestSetDepth(t *T) {
+	s := NewSet()
+	for i := 0; i < 10000; i++ {
+		s, _ = s.SetVal(fmt.Sprintf("key-%d", i))
+	}
+	assert.True(t, setDepth(s) <= hashLevels)
+}
+
+type hashPoint struct {
+	x, y int
+	name string
+	tag  interface{}
+}
+
+// Test that every kind of value which == can compare can be put in a Set, and
+// that values which are == are treated as the same element
+func TestSetHashKinds(t *T) {
+	assert.NotEqual(t, hash(1.2, 0), hash(1.7, 0))
+	assert.NotEqual(t, hash(float32(1.2), 0), hash(float32(1.7), 0))
+
+	ch := make(chan int)
+	a, b := new(int), new(int)
+	vals := []interface{}{
+		1.2, 1.7, float32(1.2), float32(1.7),
+		true, false, nil,
+		complex(1, 2), complex(2, 1), complex64(complex(1, 2)),
+		a, b, ch,
+		hashPoint{1, 2, "a", nil}, hashPoint{2, 1, "a", nil},
+		hashPoint{1, 2, "a", "tag"}, hashPoint{1, 2, "a", a},
+		[3]int{1, 2, 3}, [3]int{3, 2, 1},
+	}
+	s := NewSet(vals...)
+	assertSeqContentsSet(t, vals, s)
+	for _, v := range vals {
+		_, ok := s.GetVal(v)
+		assert.True(t, ok)
+	}
+
+	// Equal values of the same type are the same element
+	negZero := math.Copysign(0, -1)
+	s = NewSet(0.0)
+	s, ok := s.SetVal(negZero)
+	assert.False(t, ok)
+	s, ok = s.SetVal(hashPoint{1, 2, "a", "tag"})
+	assert.True(t, ok)
+	s, ok = s.SetVal(hashPoint{1, 2, "a", "tag"})
+	assert.False(t, ok)
+	_, ok = s.GetVal([3]int{1, 2, 3})
+	assert.False(t, ok)
+
+	// NaN is never equal to itself
+	s = NewSet(math.NaN(), math.NaN())
+	assert.Equal(t, uint64(2), s.Size())
+	_, ok = s.GetVal(math.NaN())
+	assert.False(t, ok)
+
+	// Struct keys work in a HashMap
+	m, _ := NewHashMap().Set(hashPoint{1, 2, "a", nil}, "one")
+	v, ok := m.Get(hashPoint{1, 2, "a", nil})
+	assert.True(t, ok)
+	assert.Equal(t, "one", v)
+
+	// Uncomparable values still can't be hashed
+	assert.Panics(t, func() { NewSet(map[int]int{}) })
+	assert.Panics(t, func() { NewSet(struct{ s []int }{}) })
+}
+
+// Test that a Set of KVs can be searched with raw keys, since a KV is equal to
+// its key and so must hash the same
+func TestSetKVRawKey(t *T) {
+	hm := NewHashMap(KeyVal("a", 1), KeyVal(2, "b"), KeyVal(NewList(3), 3))
+	set := ToSet(hm)
+	for _, key := range []interface{}{"a", 2, NewList(3)} {
+		assert.Equal(t, hash(key, 0), hash(KeyVal(key, nil), 0))
+		_, ok := set.GetVal(key)
+		assert.True(t, ok)
+	}
+	_, ok := set.GetVal("b")
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), set.Intersection(NewList("a")).Size())
+	assert.Equal(t, uint64(2), set.Difference(NewList("a")).Size())
+}
+
+// Test that the Try methods return an ErrUnhashable for values which can't be
+// hashed, and that the other methods panic with it
+func TestSetUnhashable(t *T) {
+	bad := map[string]int{}
+	badType := reflect.TypeOf(bad)
+	expErr := ErrUnhashable{badType}
+
+	_, err := TryNewSet(1, bad)
+	assert.Equal(t, expErr, err)
+	assert.Equal(t, "map[string]int not hashable", err.Error())
+
+	s := NewSet(1, 2)
+	s2, ok, err := s.TrySetVal(bad)
+	assert.Equal(t, expErr, err)
+	assert.False(t, ok)
+	assert.True(t, s == s2)
+
+	s2, ok, err = s.TryDelVal(bad)
+	assert.Equal(t, expErr, err)
+	assert.False(t, ok)
+	assert.True(t, s == s2)
+
+	_, ok, err = s.TryGetVal(bad)
+	assert.Equal(t, expErr, err)
+	assert.False(t, ok)
+
+	_, err = TryToSet(NewList(1, bad))
+	assert.Equal(t, expErr, err)
+
+	// Nested within something which is otherwise hashable
+	_, _, err = s.TrySetVal([1]interface{}{bad})
+	assert.Equal(t, ErrUnhashable{reflect.TypeOf([1]interface{}{})}, err)
+
+	// Hashable values still work
+	s2, ok, err = s.TrySetVal(3)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assertSeqContentsSet(t, []interface{}{1, 2, 3}, s2)
+
+	assert.Panics(t, func() { s.SetVal(bad) })
+	assert.Panics(t, func() { s.GetVal(bad) })
+	assert.Panics(t, func() { s.DelVal(bad) })
+}
+
+// Asserts the structural invariants of a Set:
+//
+//   - every value is stored with its hash, under that hash's prefix
+//   - every node's bitmap has a bit set for each of its entries
+//   - every node's size is the number of values under it, and the root's is
+//     the Set's Size
+//   - the tree is canonical: every node but the root holds at least two values
+//   - a collision node only holds values which share a full hash, and none of
+//     them are equal to each other
+func assertSaneSet(t *T, set *Set) {
+	if set == nil {
+		return
+	}
+	var walk func(n *setNode, depth, prefix uint32) uint64
+	walk = func(n *setNode, depth, prefix uint32) uint64 {
+		assert.True(t, len(n.entries) > 0)
+		mask := ^uint32(0)
+		if depth < hashLevels {
+			mask = uint32(1)<<(depth*hashBits) - 1
+			assert.Equal(t, len(n.entries), bits.OnesCount32(n.bitmap))
+		} else {
+			assert.Equal(t, uint32(0), n.bitmap)
+		}
+
+		var count uint64
+		bitmap := n.bitmap
+		for i, e := range n.entries {
+			slotPrefix := prefix
+			if depth < hashLevels {
+				slot := uint32(bits.TrailingZeros32(bitmap))
+				bitmap &= bitmap - 1
+				slotPrefix |= slot << (depth * hashBits)
+			}
+
+			if e.node != nil {
+				assert.True(t, depth < hashLevels)
+				assert.Nil(t, e.val)
+				count += walk(e.node, depth+1, slotPrefix)
+				continue
+			}
+			assert.Equal(t, hash(e.val, 0), e.h)
+			assert.Equal(t, prefix, e.h&mask)
+			if depth >= hashLevels {
+				for _, e2 := range n.entries[i+1:] {
+					assert.Equal(t, e.h, e2.h)
+					assert.False(t, equal(e.val, e2.val))
+				}
+			}
+			count++
+		}
+		assert.Equal(t, count, n.size)
+		if depth > 0 {
+			assert.True(t, count >= 2)
+		}
+		return count
+	}
+	assert.Equal(t, set.Size(), walk(set.root, 0, 0))
+}
+
+// Test that deleting values from a Set removes the nodes they were in, so that
+// its size and depth shrink along with it
+func TestSetDelCompaction(t *T) {
+	s := NewSet()
+	for i := 0; i < 10000; i++ {
+		s, _ = s.SetVal(i)
+	}
+	assertSaneSet(t, s)
+	deep := setDepth(s)
+
+	var ok bool
+	for i := 0; i < 9990; i++ {
+		s, ok = s.DelVal(i)
+		assert.True(t, ok)
+		if i%1000 == 0 {
+			assertSaneSet(t, s)
+		}
+	}
+	assertSaneSet(t, s)
+	assert.Equal(t, uint64(10), s.Size())
+	assert.True(t, setDepth(s) < deep)
+	assertSeqContentsSet(t, ToSlice(NewList(9990, 9991, 9992, 9993, 9994,
+		9995, 9996, 9997, 9998, 9999)), s)
+
+	for i := 9990; i < 10000; i++ {
+		s, _ = s.DelVal(i)
+	}
+	assert.Nil(t, s)
+
+	// Collision nodes are removed once empty as well
+	for i := 0; i < 10; i++ {
+		s, _ = s.SetVal(collider(i))
+	}
+	assertSaneSet(t, s)
+	for i := 0; i < 10; i++ {
+		s, _ = s.DelVal(collider(i))
+		assertSaneSet(t, s)
+	}
+	assert.Nil(t, s)
+
+	// As are nodes left empty by FirstRest
+	s = NewSet()
+	for i := 0; i < 1000; i++ {
+		s, _ = s.SetVal(i)
+	}
+	for i := 0; i < 990; i++ {
+		_, rest, _ := s.FirstRest()
+		s = rest.(*Set)
+	}
+	assertSaneSet(t, s)
+}
+
+func BenchmarkSetGetValInt(b *B) {
+	s := benchSet(1000)
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		s.GetVal(i % 1000)
+	}
+}
+
+func BenchmarkSetDelVal(b *B) {
+	s := benchSet(1000)
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		s.DelVal(i % 1000)
+	}
+}
+
+// Reports the heap memory retained by a Set per element in it
+func BenchmarkSetMemory(b *B) {
+	vals := make([]interface{}, 100000)
+	for i := range vals {
+		vals[i] = i
+	}
+	var before, after runtime.MemStats
+	var perElem float64
+	for b.Loop() {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		s := NewSet(vals...)
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(s)
+		perElem = float64(after.HeapAlloc-before.HeapAlloc) / float64(len(vals))
+	}
+	b.ReportMetric(perElem, "B/elem")
+}
+
+// Test that the shape of a Set depends only on the values in it, and not on
+// the order they were set and deleted in
+func TestSetCanonical(t *T) {
+	s1 := NewSet()
+	for i := 0; i < 2000; i++ {
+		s1, _ = s1.SetVal(i)
+	}
+	for i := 0; i < 2000; i += 2 {
+		s1, _ = s1.DelVal(i)
+	}
+
+	s2 := NewSet()
+	for i := 1999; i >= 1; i -= 2 {
+		s2, _ = s2.SetVal(i)
+	}
+
+	assertSaneSet(t, s1)
+	assertSaneSet(t, s2)
+	assert.True(t, reflect.DeepEqual(s1, s2))
+}
+
+// Returns a random Set of ints and colliders, along with the same values in a
+// map
+func randSet(r *rand.Rand, n int) (*Set, map[interface{}]bool) {
+	s, m := NewSet(), map[interface{}]bool{}
+	for i := 0; i < n; i++ {
+		var v interface{} = r.Intn(n * 2)
+		if r.Intn(10) == 0 {
+			v = collider(r.Intn(20))
+		}
+		s, _ = s.SetVal(v)
+		m[v] = true
+	}
+	return s, m
+}
+
+// Test that Union, Intersection, Difference and SymDifference give the same
+// results when merging two Sets node by node as they do for any other Seq
+func TestSetStructuralOps(t *T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 10, 100, 2000} {
+		s1, m1 := randSet(r, n)
+		s2, m2 := randSet(r, n)
+
+		var union, inter, diff, sym []interface{}
+		for v := range m1 {
+			union = append(union, v)
+			if m2[v] {
+				inter = append(inter, v)
+			} else {
+				diff = append(diff, v)
+				sym = append(sym, v)
+			}
+		}
+		for v := range m2 {
+			if !m1[v] {
+				union = append(union, v)
+				sym = append(sym, v)
+			}
+		}
+
+		for _, c := range []struct {
+			vals []interface{}
+			set  *Set
+		}{
+			{union, s1.Union(s2)},
+			{inter, s1.Intersection(s2)},
+			{diff, s1.Difference(s2)},
+			{sym, s1.SymDifference(s2)},
+		} {
+			assertSaneSet(t, c.set)
+			assert.True(t, NewSet(c.vals...).Equal(c.set))
+		}
+		assert.True(t, s1.Intersection(s2).Equal(s1.Intersection(ToList(s2))))
+		assert.True(t, s1.Difference(s2).Equal(s1.Difference(ToList(s2))))
+		assert.True(t, s1.SymDifference(s2).Equal(s1.SymDifference(ToList(s2))))
+
+		assert.Equal(t, len(diff) == 0, s1.IsSubset(s2))
+		assert.Equal(t, len(diff) == 0, s2.IsSuperset(s1))
+		assert.Equal(t, len(inter) == 0, s1.Disjoint(s2))
+		assert.True(t, s1.IsSubset(s1.Union(s2)))
+		assert.True(t, s1.Disjoint(s2.Difference(s1)))
+	}
+}
+
+// Test that merging two versions of the same Set reuses the subtrees they
+// have in common
+func TestSetStructuralSharing(t *T) {
+	nodes := func(set *Set) map[*setNode]bool {
+		m := map[*setNode]bool{}
+		var walk func(*setNode)
+		walk = func(n *setNode) {
+			m[n] = true
+			for _, e := range n.entries {
+				if e.node != nil {
+					walk(e.node)
+				}
+			}
+		}
+		if set != nil {
+			walk(set.root)
+		}
+		return m
+	}
+	newNodes := func(set *Set, from ...*Set) int {
+		var count int
+		old := map[*setNode]bool{}
+		for _, s := range from {
+			for n := range nodes(s) {
+				old[n] = true
+			}
+		}
+		for n := range nodes(set) {
+			if !old[n] {
+				count++
+			}
+		}
+		return count
+	}
+
+	s := benchSet(10000)
+	s2, _ := s.SetVal(-1)
+	s2, _ = s2.DelVal(5)
+
+	assert.Equal(t, s.root, s.Union(s).root)
+	assert.Equal(t, s.root, s.Intersection(s).root)
+	assert.Nil(t, s.Difference(s))
+	assert.Nil(t, s.SymDifference(s))
+	assert.True(t, s.IsSubset(s))
+	assert.False(t, s.Disjoint(s))
+
+	// Only the nodes along the paths to the two changes can be new
+	limit := 2 * setDepth(s2)
+	for _, set := range []*Set{
+		s.Union(s2),
+		s.Intersection(s2),
+		s.Difference(s2),
+		s.SymDifference(s2),
+	} {
+		assertSaneSet(t, set)
+		assert.True(t, newNodes(set, s, s2) <= limit)
+	}
+	assert.Equal(t, uint64(10001), s.Union(s2).Size())
+	assert.Equal(t, uint64(9999), s.Intersection(s2).Size())
+	assert.True(t, NewSet(5).Equal(s.Difference(s2)))
+	assert.True(t, NewSet(-1, 5).Equal(s.SymDifference(s2)))
+	assert.False(t, s.IsSubset(s2))
+	assert.True(t, s.Difference(s2).IsSubset(s))
+	assert.True(t, s.IsSuperset(s2.Intersection(s)))
+}
+
+func BenchmarkSetUnionVersions(b *B) {
+	s := benchSet(10000)
+	s2, _ := s.SetVal(-1)
+	s2, _ = s2.DelVal(5)
+	b.ReportAllocs()
+	for b.Loop() {
+		s.Union(s2)
+	}
+}
+
+// Asserts that the Set holds exactly the values in the map, and that its Size
+// agrees
+func assertSetModel(t *T, m map[interface{}]bool, set *Set) {
+	assertSaneSet(t, set)
+	assert.Equal(t, uint64(len(m)), set.Size())
+	assert.Equal(t, len(m), len(ToSlice(set)))
+	for v := range m {
+		_, ok := set.GetVal(v)
+		assert.True(t, ok)
+	}
+}
+
+// Test that Size always matches the real number of values in a Set, by making
+// random changes to one and to a map alongside it
+func TestSetModel(t *T) {
+	r := rand.New(rand.NewSource(1))
+	randVal := func() interface{} {
+		if r.Intn(10) == 0 {
+			return collider(r.Intn(10))
+		}
+		return r.Intn(500)
+	}
+
+	var set *Set
+	m := map[interface{}]bool{}
+	for i := 0; i < 3000; i++ {
+		switch r.Intn(8) {
+		case 0, 1, 2:
+			v := randVal()
+			var ok bool
+			set, ok = set.SetVal(v)
+			assert.Equal(t, !m[v], ok)
+			m[v] = true
+		case 3, 4:
+			v := randVal()
+			var ok bool
+			set, ok = set.DelVal(v)
+			assert.Equal(t, m[v], ok)
+			delete(m, v)
+		case 5:
+			// Either a Set or a List, with some values already in set
+			vals := []interface{}{randVal(), randVal(), randVal()}
+			var s Seq = NewList(vals...)
+			if r.Intn(2) == 0 {
+				s = NewSet(vals...)
+			}
+			set = set.Union(s)
+			for _, v := range vals {
+				m[v] = true
+			}
+		case 6:
+			vals := []interface{}{randVal(), randVal(), randVal()}
+			var s Seq = NewList(vals...)
+			if r.Intn(2) == 0 {
+				s = NewSet(vals...)
+			}
+			set = set.SymDifference(s)
+			for v := range Values(ToSet(s)) {
+				if m[v] {
+					delete(m, v)
+				} else {
+					m[v] = true
+				}
+			}
+		case 7:
+			el, rest, ok := set.FirstRest()
+			assert.Equal(t, len(m) > 0, ok)
+			delete(m, el)
+			set = rest.(*Set)
+		}
+		assertSetModel(t, m, set)
+		assertSaneSet(t, set)
+	}
+}
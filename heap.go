@@ -0,0 +1,185 @@
+package seq
+
+import "slices"
+
+// Heap is built on a persistent leftist heap. Each node's value sorts before
+// or equal to those of its children, and each node's rank, the length of the
+// path down its right children, is no more than that of its left child. Since
+// that keeps the right path of any node at O(log(N)) long, and merging two
+// heaps only ever walks down right paths, every operation has a worst case
+// bound rather than an amortized one, which matters when the same version of a
+// Heap may be popped from more than once.
+
+type heapNode struct {
+	val         interface{}
+	left, right *heapNode
+	rank        int
+}
+
+func (n *heapNode) r() int {
+	if n == nil {
+		return 0
+	}
+	return n.rank
+}
+
+// Returns a new node with the given value and children, swapping the children
+// if needed to keep the node leftist
+func newHeapNode(val interface{}, left, right *heapNode) *heapNode {
+	if left.r() < right.r() {
+		left, right = right, left
+	}
+	return &heapNode{val, left, right, right.r() + 1}
+}
+
+// Returns a node holding the values of both given nodes
+func heapMerge(cmp CompareFn, a, b *heapNode) *heapNode {
+	if a == nil {
+		return b
+	} else if b == nil {
+		return a
+	} else if cmp(b.val, a.val) < 0 {
+		a, b = b, a
+	}
+	return newHeapNode(a.val, a.left, heapMerge(cmp, a.right, b))
+}
+
+// Heap is an implementation of Seq in the form of a persistent priority queue.
+// Its elements are ordered by a CompareFn, and the smallest can be looked at or
+// removed at any time. Unlike a SortedSet a Heap may hold any number of
+// elements which compare as equal. Walking a Heap with FirstRest yields its
+// elements smallest first.
+//
+// Heaps must be created with NewHeap, so they know their CompareFn. A nil *Heap
+// can be read from as an empty Heap, but not inserted into.
+type Heap struct {
+	cmp  CompareFn
+	root *heapNode
+	size uint64
+}
+
+// NewHeap returns a new Heap, ordered by the given CompareFn, of the given
+// elements (or no elements, for an empty Heap)
+func NewHeap(cmp CompareFn, vals ...interface{}) *Heap {
+	h := &Heap{cmp: cmp}
+	for i := range vals {
+		h = h.Insert(vals[i])
+	}
+	return h
+}
+
+// Size returns the number of elements in the Heap. Completes in O(1) time.
+func (h *Heap) Size() uint64 {
+	if h == nil {
+		return 0
+	}
+	return h.size
+}
+
+// Insert returns a new Heap with the given value added to it. Completes in
+// O(log(N)) time.
+func (h *Heap) Insert(val interface{}) *Heap {
+	root := heapMerge(h.cmp, h.root, &heapNode{val: val, rank: 1})
+	return &Heap{h.cmp, root, h.size + 1}
+}
+
+// PeekMin returns the smallest element in the Heap, and true. If the Heap is
+// empty returns nil and false. Completes in O(1) time.
+func (h *Heap) PeekMin() (interface{}, bool) {
+	if h == nil || h.root == nil {
+		return nil, false
+	}
+	return h.root.val, true
+}
+
+// PopMin returns the smallest element in the Heap, a new Heap with that element
+// removed, and true. If the Heap is empty returns nil, the empty Heap, and
+// false. Completes in O(log(N)) time.
+func (h *Heap) PopMin() (interface{}, *Heap, bool) {
+	if h == nil || h.root == nil {
+		return nil, h, false
+	}
+	root := heapMerge(h.cmp, h.root.left, h.root.right)
+	return h.root.val, &Heap{h.cmp, root, h.size - 1}, true
+}
+
+// Merge returns a new Heap holding the elements of both this Heap and the given
+// one, which must be ordered by the same CompareFn. Completes in O(log(N+M))
+// time.
+func (h *Heap) Merge(h2 *Heap) *Heap {
+	if h2.Size() == 0 {
+		return h
+	} else if h.Size() == 0 {
+		return h2
+	}
+	return &Heap{h.cmp, heapMerge(h.cmp, h.root, h2.root), h.size + h2.size}
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. It is the same
+// as PopMin.
+func (h *Heap) FirstRest() (interface{}, Seq, bool) {
+	el, nh, ok := h.PopMin()
+	return el, nh, ok
+}
+
+// Hash implements the Hash method for the Setable interface
+func (h *Heap) Hash(i uint32) uint32 {
+	sum := uint32(0)
+	for el := range Values(h) {
+		sum += hash(el, i)
+	}
+	return sum
+}
+
+// Pops every element at the front of the Heap which compares as equal to val,
+// appending them to run. Returns the run and the Heap without them.
+func (h *Heap) popRun(run []interface{}, val interface{}) ([]interface{}, *Heap) {
+	for {
+		el, ok := h.PeekMin()
+		if !ok || h.cmp(el, val) != 0 {
+			return run, h
+		}
+		run = append(run, el)
+		_, h, _ = h.PopMin()
+	}
+}
+
+// Equal implements the Equal method for the Comparable and Setable interfaces.
+// Two Heaps are equal if they hold the same number of each equal element. Which
+// of the elements that compare as equal is popped first depends on the shape of
+// the Heap, so each run of them is compared without regard to order, taking
+// O(K^2) time for a run of K.
+func (h *Heap) Equal(v interface{}) bool {
+	h2, ok := v.(*Heap)
+	if !ok || h.Size() != h2.Size() {
+		return false
+	}
+
+	var run, run2 []interface{}
+	for {
+		first, ok := h.PeekMin()
+		if !ok {
+			return true
+		}
+		run, h = h.popRun(run[:0], first)
+		run2, h2 = h2.popRun(run2[:0], first)
+		if len(run) != len(run2) {
+			return false
+		}
+		for _, el := range run {
+			i := slices.IndexFunc(run2, func(el2 interface{}) bool {
+				return equal(el, el2)
+			})
+			if i < 0 {
+				return false
+			}
+			run2[i] = run2[len(run2)-1]
+			run2 = run2[:len(run2)-1]
+		}
+	}
+}
+
+// String is an implementation of String for Stringer interface
+func (h *Heap) String() string {
+	return ToString(h, "<", ">")
+}
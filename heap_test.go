@@ -0,0 +1,134 @@
+package seq
+
+import (
+	"math/rand"
+	"sort"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Asserts that the given heap is ordered and leftist, and returns its size
+func assertSaneHeap(t *T, n *heapNode) uint64 {
+	if n == nil {
+		return 0
+	}
+	assert.Equal(t, true, n.left.r() >= n.right.r())
+	assert.Equal(t, n.right.r()+1, n.rank)
+	for _, kid := range []*heapNode{n.left, n.right} {
+		if kid != nil {
+			assert.Equal(t, true, kid.val.(int) >= n.val.(int))
+		}
+	}
+	return 1 + assertSaneHeap(t, n.left) + assertSaneHeap(t, n.right)
+}
+
+// Test creating a Heap and calling the Seq interface methods on it
+func TestHeapSeq(t *T) {
+	h := NewHeap(compareInts, 3, 1, 2, 5, 4, 2)
+	testSeqGen(t, h, []interface{}{1, 2, 2, 3, 4, 5})
+	assert.Equal(t, "< 1 2 2 3 4 5 >", h.String())
+
+	empty := NewHeap(compareInts)
+	assert.Equal(t, uint64(0), Size(empty))
+	assert.Equal(t, 0, len(ToSlice(empty)))
+	_, ok := empty.PeekMin()
+	assert.Equal(t, false, ok)
+
+	// Existing helpers work on it
+	assert.Equal(t, []interface{}{1, 2}, ToSlice(Take(2, h)))
+	even := func(el interface{}) bool { return el.(int)%2 == 0 }
+	assert.Equal(t, []interface{}{2, 2, 4}, ToSlice(LFilter(even, h)))
+}
+
+// Test inserting and popping many values from a Heap, checking that it stays
+// ordered and leftist, and that old versions are unaffected
+func TestHeapInsertPop(t *T) {
+	r := rand.New(rand.NewSource(1))
+	h := NewHeap(compareInts)
+	var ints []int
+	for i := 0; i < 2000; i++ {
+		prev, prevInts := h, append([]int(nil), ints...)
+		if r.Intn(3) == 0 {
+			el, nh, ok := h.PopMin()
+			assert.Equal(t, len(ints) > 0, ok)
+			if ok {
+				assert.Equal(t, ints[0], el)
+				ints = ints[1:]
+			}
+			h = nh
+		} else {
+			v := r.Intn(500)
+			h = h.Insert(v)
+			ints = append(ints, v)
+			sort.Ints(ints)
+		}
+
+		assert.Equal(t, uint64(len(ints)), assertSaneHeap(t, h.root))
+		assert.Equal(t, uint64(len(ints)), h.Size())
+		if el, ok := h.PeekMin(); len(ints) > 0 {
+			assert.Equal(t, true, ok)
+			assert.Equal(t, ints[0], el)
+		}
+		assert.Equal(t, uint64(len(prevInts)), assertSaneHeap(t, prev.root))
+		if el, ok := prev.PeekMin(); len(prevInts) > 0 {
+			assert.Equal(t, true, ok)
+			assert.Equal(t, prevInts[0], el)
+		}
+	}
+
+	sorted := ToSlice(h)
+	assert.Equal(t, len(ints), len(sorted))
+	for i := range sorted {
+		assert.Equal(t, ints[i], sorted[i])
+	}
+}
+
+// Test merging Heaps together
+func TestHeapMerge(t *T) {
+	h1 := NewHeap(compareInts, 5, 1, 9, 3)
+	h2 := NewHeap(compareInts, 4, 1, 8)
+	empty := NewHeap(compareInts)
+
+	h := h1.Merge(h2)
+	assert.Equal(t, uint64(7), assertSaneHeap(t, h.root))
+	assert.Equal(t, []interface{}{1, 1, 3, 4, 5, 8, 9}, ToSlice(h))
+	assert.Equal(t, []interface{}{1, 3, 5, 9}, ToSlice(h1))
+	assert.Equal(t, []interface{}{1, 4, 8}, ToSlice(h2))
+
+	assert.Equal(t, true, h1.Merge(empty) == h1)
+	assert.Equal(t, true, empty.Merge(h1) == h1)
+	assert.Equal(t, true, h1.Merge(nil) == h1)
+	assert.Equal(t, uint64(0), empty.Merge(empty).Size())
+}
+
+// Test that two Heaps compare equality correctly
+func TestHeapEqual(t *T) {
+	h1 := NewHeap(compareInts, 3, 1, 2)
+	h2 := NewHeap(compareInts, 1).Merge(NewHeap(compareInts, 2, 3))
+	assert.Equal(t, true, h1.Equal(h2))
+	assert.Equal(t, h1.Hash(0), h2.Hash(0))
+	assert.Equal(t, false, h1.Equal(h2.Insert(4)))
+	assert.Equal(t, false, h1.Equal(NewHeap(compareInts, 3, 1, 4)))
+	assert.Equal(t, false, h1.Equal(NewSortedSet(compareInts, 1, 2, 3)))
+	assert.Equal(t, true, NewHeap(compareInts).Equal(NewHeap(compareInts)))
+
+	// Elements which compare as equal may be popped in a different order from
+	// Heaps holding the same elements
+	compareFirst := func(a, b interface{}) int {
+		a0, _ := a.(*Vector).Nth(0)
+		b0, _ := b.(*Vector).Nth(0)
+		return compareInts(a0, b0)
+	}
+	a, b, c := NewVector(1, "a"), NewVector(1, "b"), NewVector(1, "c")
+	x, y := NewVector(0, "x"), NewVector(2, "y")
+	h1 = NewHeap(compareFirst, a, b, c, x, y)
+	h2 = NewHeap(compareFirst, y, c, b, x, a)
+	assert.NotEqual(t, ToSlice(h1), ToSlice(h2))
+	assert.Equal(t, true, h1.Equal(h2))
+	assert.Equal(t, true, h2.Equal(h1))
+	assert.Equal(t, h1.Hash(0), h2.Hash(0))
+	h3 := NewHeap(compareFirst, a, b, b, x, y)
+	assert.Equal(t, false, h1.Equal(h3))
+	assert.Equal(t, false, h3.Equal(h1))
+}
@@ -0,0 +1,148 @@
+package seq
+
+import (
+	"iter"
+	"runtime"
+)
+
+// Values returns an iterator over the elements of the given Seq, for use with
+// range. The elements are yielded in the same order FirstRest would return
+// them. Each call to the returned iterator starts from the beginning of the
+// Seq. Set, HashMap, Vector, Queue, Deque, SortedSet, SortedMap and OrderedMap
+// are walked directly, without the allocations which calling FirstRest on them
+// repeatedly would incur.
+//
+//	for el := range seq.Values(s) {
+//		...
+//	}
+func Values(s Seq) iter.Seq[interface{}] {
+	switch st := s.(type) {
+	case *Set:
+		return st.each
+	case *HashMap:
+		if st == nil {
+			return (*Set)(nil).each
+		}
+		return st.set.each
+	case *Vector:
+		return st.each
+	case *Queue:
+		return st.each
+	case *Deque:
+		return st.each
+	case *SortedSet:
+		return st.each
+	case *SortedMap:
+		return st.sset().each
+	case *OrderedMap:
+		return st.each
+	}
+
+	return func(yield func(interface{}) bool) {
+		var el interface{}
+		var ok bool
+		for cur := s; ; {
+			if el, cur, ok = cur.FirstRest(); !ok || !yield(el) {
+				return
+			}
+		}
+	}
+}
+
+// Indexed returns an iterator over the index and value of each element in the
+// List, for use with range.
+func (l *List) Indexed() iter.Seq2[int, interface{}] {
+	return func(yield func(int, interface{}) bool) {
+		for i, cur := 0, l; cur != nil; i, cur = i+1, cur.next {
+			if !yield(i, cur.el) {
+				return
+			}
+		}
+	}
+}
+
+// Indexed returns an iterator over the index and value of each element in the
+// Vector, for use with range.
+func (v *Vector) Indexed() iter.Seq2[int, interface{}] {
+	return func(yield func(int, interface{}) bool) {
+		i := 0
+		v.each(func(el interface{}) bool {
+			i++
+			return yield(i-1, el)
+		})
+	}
+}
+
+// Returns an iterator over the keys and values of the KVs in the given Seq
+func kvPairs(s Seq) iter.Seq2[interface{}, interface{}] {
+	return func(yield func(interface{}, interface{}) bool) {
+		for el := range Values(s) {
+			if kv := el.(*KV); !yield(kv.Key, kv.Val) {
+				return
+			}
+		}
+	}
+}
+
+// Pairs returns an iterator over the key and value of each KV in the HashMap,
+// for use with range.
+func (hm *HashMap) Pairs() iter.Seq2[interface{}, interface{}] {
+	return kvPairs(hm)
+}
+
+// Pairs returns an iterator over the key and value of each KV in the
+// SortedMap, in ascending key order, for use with range.
+func (sm *SortedMap) Pairs() iter.Seq2[interface{}, interface{}] {
+	return kvPairs(sm)
+}
+
+// Pairs returns an iterator over the key and value of each KV in the
+// OrderedMap, in insertion order, for use with range.
+func (om *OrderedMap) Pairs() iter.Seq2[interface{}, interface{}] {
+	return kvPairs(om)
+}
+
+// puller holds the functions returned by iter.Pull, so that they can be
+// stopped once the Lazy reading from them is no longer referenced
+type puller struct {
+	next func() (interface{}, bool)
+	stop func()
+}
+
+func newPuller(next func() (interface{}, bool), stop func()) *puller {
+	p := &puller{next, stop}
+	runtime.AddCleanup(p, func(stop func()) { stop() }, stop)
+	return p
+}
+
+func (p *puller) thunk() Thunk {
+	return func() (interface{}, Thunk, bool) {
+		el, ok := p.next()
+		if !ok {
+			p.stop()
+			return nil, nil, false
+		}
+		return el, p.thunk(), true
+	}
+}
+
+// FromIter returns a Lazy which yields the values of the given iterator. The
+// iterator is advanced as the Lazy is evaluated, using iter.Pull, and is
+// stopped either once it is exhausted or once the Lazy is garbage collected.
+func FromIter(it iter.Seq[interface{}]) *Lazy {
+	return NewLazy(newPuller(iter.Pull(it)).thunk())
+}
+
+// FromIter2 returns a Lazy which yields a KV for each key/value pair of the
+// given iterator. It otherwise behaves the same as FromIter.
+func FromIter2(it iter.Seq2[interface{}, interface{}]) *Lazy {
+	next2, stop := iter.Pull2(it)
+	next := func() (interface{}, bool) {
+		k, v, ok := next2()
+		if !ok {
+			return nil, false
+		}
+		return KeyVal(k, v), true
+	}
+	return NewLazy(newPuller(next, stop).thunk())
+}
@@ -0,0 +1,106 @@
+package seq
+
+import (
+	"maps"
+	"slices"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test ranging over the values of every Seq type
+func TestValues(t *T) {
+	ints := []interface{}{0, 1, 2, 3, 4}
+	seqs := []Seq{
+		NewList(ints...),
+		NewVector(ints...),
+		NewSet(ints...),
+		NewSortedSet(compareInts, ints...),
+		ToLazy(NewList(ints...)),
+	}
+	for _, s := range seqs {
+		var got []interface{}
+		for el := range Values(s) {
+			got = append(got, el)
+		}
+		assertSeqContentsSet(t, ints, NewList(got...))
+
+		// Iterating again starts from the beginning
+		assert.Equal(t, len(ints), len(slices.Collect(Values(s))))
+	}
+
+	// Breaking out of the range early
+	var got []interface{}
+	for el := range Values(NewList(ints...)) {
+		if el.(int) == 2 {
+			break
+		}
+		got = append(got, el)
+	}
+	assert.Equal(t, []interface{}{0, 1}, got)
+
+	var empty *List
+	assert.Equal(t, 0, len(slices.Collect(Values(empty))))
+}
+
+// Test ranging over the indices and values of Lists and Vectors
+func TestIndexed(t *T) {
+	ints := []interface{}{"a", "b", "c"}
+	for _, it := range []func(func(int, interface{}) bool){
+		NewList(ints...).Indexed(),
+		NewVector(ints...).Indexed(),
+	} {
+		var got []interface{}
+		for i, el := range it {
+			assert.Equal(t, ints[i], el)
+			got = append(got, el)
+			if i == 1 {
+				break
+			}
+		}
+		assert.Equal(t, ints[:2], got)
+	}
+}
+
+// Test ranging over the keys and values of maps
+func TestPairs(t *T) {
+	exp := map[interface{}]interface{}{1: "one", 2: "two", 3: "three"}
+	hm := NewHashMap(KeyVal(1, "one"), KeyVal(2, "two"), KeyVal(3, "three"))
+	assert.Equal(t, exp, maps.Collect(hm.Pairs()))
+
+	sm := NewSortedMap(compareInts, KeyVal(3, "three"), KeyVal(1, "one"), KeyVal(2, "two"))
+	var keys []interface{}
+	for k, v := range sm.Pairs() {
+		assert.Equal(t, exp[k], v)
+		keys = append(keys, k)
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, keys)
+}
+
+// Test creating Lazys from iterators
+func TestFromIter(t *T) {
+	ints := []interface{}{0, 1, 2, 3, 4}
+	l := FromIter(slices.Values(ints))
+	assert.Equal(t, ints, ToSlice(l))
+	assert.Equal(t, ints, ToSlice(l))
+	assert.Equal(t, []interface{}{0, 2, 4}, ToSlice(LFilter(func(el interface{}) bool {
+		return el.(int)%2 == 0
+	}, FromIter(slices.Values(ints)))))
+
+	// Only as much of the iterator is consumed as is needed
+	var yielded int
+	l = FromIter(func(yield func(interface{}) bool) {
+		for i := 0; ; i++ {
+			yielded++
+			if !yield(i) {
+				return
+			}
+		}
+	})
+	assert.Equal(t, []interface{}{0, 1, 2}, ToSlice(Take(3, l)))
+	assert.Equal(t, 3, yielded)
+
+	m := map[interface{}]interface{}{1: "one", 2: "two"}
+	l = FromIter2(maps.All(m))
+	assertSeqContentsHashMap(t, []*KV{KeyVal(1, "one"), KeyVal(2, "two")}, l)
+}
@@ -0,0 +1,461 @@
+package seq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Every type in this package can be marshaled to JSON. Those without an order
+// to their elements, or whose order is kept, are marshaled as arrays. Maps
+// are marshaled as objects when all of their keys are strings, and as arrays
+// of [key, value] pairs otherwise. A nil pointer to one of them, which is how
+// each is empty, is marshaled as an empty array or object when it's held in
+// another of them, though encoding/json itself marshals it as null anywhere
+// else. The types which can be created without a
+// CompareFn can also be unmarshaled from the same forms, with their elements
+// decoded the way encoding/json decodes into an interface{}. Unmarshaling null
+// into one leaves it unchanged, and unmarshaling an empty array makes it empty,
+// except for a List, see ErrEmptyJSONList. DecodeJSON instead decodes nested objects and arrays into nested HashMaps and
+// Lists.
+
+// ErrEmptyJSONList is returned when unmarshaling an empty JSON array into a
+// List. The empty List is the nil *List, but encoding/json always unmarshals
+// into a List it has allocated, and a List can't be made nil in place. For a
+// *List which may be empty use null instead, or decode with DecodeJSON, which
+// decodes an empty array as a nil *List.
+var ErrEmptyJSONList = errors.New("can't unmarshal empty JSON array into a List, use null or DecodeJSON")
+
+// Marshals an element of one of this package's types. A nil pointer to one of
+// them is marshaled as an empty array, or an empty object for the maps, rather
+// than as null like encoding/json would.
+func marshalJSONEl(el interface{}) ([]byte, error) {
+	switch el.(type) {
+	case *List, *Vector, *Queue, *Deque, *Set, *SortedSet, *Heap, *Lazy:
+		if reflect.ValueOf(el).IsNil() {
+			return []byte("[]"), nil
+		}
+	case *HashMap, *OrderedMap, *SortedMap:
+		if reflect.ValueOf(el).IsNil() {
+			return []byte("{}"), nil
+		}
+	}
+	return json.Marshal(el)
+}
+
+// Returns the elements of the Seq as a JSON array. If the Seq is an ErrSeq it's
+// walked with FirstRest, so that the error which ended it, if any, can be
+// returned.
+func marshalJSONArray(s Seq) ([]byte, error) {
+	els := Values(s)
+	if _, ok := s.(ErrSeq); ok {
+		elsSlice, err := ToSliceErr(s)
+		if err != nil {
+			return nil, err
+		}
+		els = slices.Values(elsSlice)
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte('[')
+	first := true
+	for el := range els {
+		b, err := marshalJSONEl(el)
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(b)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// Returns the KVs of the Seq as a JSON object, in the order the Seq yields
+// them, if all of their keys are strings. Otherwise returns them as a JSON
+// array of [key, value] pairs, in the same order.
+func marshalJSONKVs(s Seq) ([]byte, error) {
+	strKeys := true
+	for el := range Values(s) {
+		if _, strKeys = el.(*KV).Key.(string); !strKeys {
+			break
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if strKeys {
+		buf.WriteByte('{')
+	} else {
+		buf.WriteByte('[')
+	}
+	first := true
+	for el := range Values(s) {
+		kv := el.(*KV)
+		key, err := marshalJSONEl(kv.Key)
+		if err != nil {
+			return nil, err
+		}
+		val, err := marshalJSONEl(kv.Val)
+		if err != nil {
+			return nil, err
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		if strKeys {
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(val)
+		} else {
+			buf.WriteByte('[')
+			buf.Write(key)
+			buf.WriteByte(',')
+			buf.Write(val)
+			buf.WriteByte(']')
+		}
+	}
+	if strKeys {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+	return buf.Bytes(), nil
+}
+
+// Returns the elements of the given JSON array, which are non-nil even if the
+// array is empty. If data is null returns nil, and the caller should leave its
+// value unchanged.
+func unmarshalJSONArray(data []byte) ([]interface{}, error) {
+	var els []interface{}
+	err := json.Unmarshal(data, &els)
+	return els, err
+}
+
+// Decodes the next JSON value from dec the way encoding/json decodes into an
+// interface{}
+func decodeJSONPlain(dec *json.Decoder) (interface{}, error) {
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
+
+// Decodes the next JSON value from dec, which must be an object or an array of
+// [key, value] pairs, calling fn with each pair in the order they appear.
+// Values are decoded with decodeJSONPlain. Returns false if the value is null.
+func decodeJSONKVs(dec *json.Decoder, fn func(key, val interface{}) error) (bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	} else if tok == nil {
+		return false, nil
+	}
+
+	var pairs bool
+	switch tok {
+	case json.Delim('{'):
+	case json.Delim('['):
+		pairs = true
+	default:
+		return false, fmt.Errorf("expected JSON object or array, got %v", tok)
+	}
+
+	for dec.More() {
+		var key, val interface{}
+		if pairs {
+			var pair []interface{}
+			if err := dec.Decode(&pair); err != nil {
+				return false, err
+			} else if len(pair) != 2 {
+				return false, fmt.Errorf("expected JSON [key, value] pair, got %v", pair)
+			}
+			key, val = pair[0], pair[1]
+		} else {
+			if key, err = dec.Token(); err != nil {
+				return false, err
+			}
+			if val, err = decodeJSONPlain(dec); err != nil {
+				return false, err
+			}
+		}
+		if err := fn(key, val); err != nil {
+			return false, err
+		}
+	}
+
+	// The closing brace or bracket
+	_, err = dec.Token()
+	return true, err
+}
+
+// DecodeJSON decodes the next JSON value from the given Decoder, building each
+// object within it into a HashMap with string keys, and each array into a
+// List, all the way down. Other values are decoded the way encoding/json
+// decodes them into an interface{}, so UseNumber can be set on the Decoder to
+// get json.Numbers rather than float64s. An empty array is decoded as a nil
+// *List.
+func DecodeJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('['):
+		var l *List
+		for dec.More() {
+			el, err := DecodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			l = l.Prepend(el)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return Reverse(l).(*List), nil
+
+	case json.Delim('{'):
+		t := (*HashMap)(nil).Transient()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			val, err := DecodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			t.Set(key, val)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return t.Persistent(), nil
+	}
+	return tok, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, marshaling the List as
+// an array
+func (l *List) MarshalJSON() ([]byte, error) {
+	return marshalJSONArray(l)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, replacing the List
+// with the elements of a JSON array. Returns ErrEmptyJSONList if the array is
+// empty.
+func (l *List) UnmarshalJSON(data []byte) error {
+	els, err := unmarshalJSONArray(data)
+	if err != nil || els == nil {
+		return err
+	} else if len(els) == 0 {
+		return ErrEmptyJSONList
+	}
+	*l = *NewList(els...)
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, marshaling the Vector as
+// an array
+func (v *Vector) MarshalJSON() ([]byte, error) {
+	return marshalJSONArray(v)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, replacing the Vector
+// with the elements of a JSON array. An empty array makes it a zero Vector.
+func (v *Vector) UnmarshalJSON(data []byte) error {
+	els, err := unmarshalJSONArray(data)
+	if err != nil || els == nil {
+		return err
+	} else if len(els) == 0 {
+		*v = Vector{}
+	} else {
+		*v = *NewVector(els...)
+	}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, marshaling the Queue as
+// an array, front first
+func (q *Queue) MarshalJSON() ([]byte, error) {
+	return marshalJSONArray(q)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, replacing the Queue
+// with the elements of a JSON array, the first at the front. An empty array
+// makes it a zero Queue.
+func (q *Queue) UnmarshalJSON(data []byte) error {
+	els, err := unmarshalJSONArray(data)
+	if err != nil || els == nil {
+		return err
+	} else if len(els) == 0 {
+		*q = Queue{}
+	} else {
+		*q = *NewQueue(els...)
+	}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, marshaling the Deque as
+// an array, front first
+func (d *Deque) MarshalJSON() ([]byte, error) {
+	return marshalJSONArray(d)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, replacing the Deque
+// with the elements of a JSON array, the first at the front. An empty array
+// makes it a zero Deque.
+func (d *Deque) UnmarshalJSON(data []byte) error {
+	els, err := unmarshalJSONArray(data)
+	if err != nil || els == nil {
+		return err
+	} else if len(els) == 0 {
+		*d = Deque{}
+	} else {
+		*d = *NewDeque(els...)
+	}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, marshaling the Set as an
+// array
+func (set *Set) MarshalJSON() ([]byte, error) {
+	return marshalJSONArray(set)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, replacing the Set
+// with the elements of a JSON array. Returns an ErrUnhashable if an element is
+// itself an object or array, since those decode as a Go map or slice; see
+// DecodeJSON for decoding those as HashMaps and Lists. An empty array makes it
+// a zero Set.
+func (set *Set) UnmarshalJSON(data []byte) error {
+	els, err := unmarshalJSONArray(data)
+	if err != nil || els == nil {
+		return err
+	} else if len(els) == 0 {
+		*set = Set{}
+		return nil
+	}
+	nset, err := TryNewSet(els...)
+	if err != nil {
+		return err
+	}
+	*set = *nset
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The HashMap is
+// marshaled as an object, with its keys sorted, if all of its keys are strings,
+// or as an array of [key, value] pairs otherwise.
+func (hm *HashMap) MarshalJSON() ([]byte, error) {
+	m := make(map[string]json.RawMessage, hm.kvSet().Size())
+	for key, val := range hm.Pairs() {
+		strKey, ok := key.(string)
+		if !ok {
+			return marshalJSONKVs(hm)
+		}
+		b, err := marshalJSONEl(val)
+		if err != nil {
+			return nil, err
+		}
+		m[strKey] = b
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, replacing the
+// HashMap with the key/value pairs of a JSON object, or of a JSON array of
+// [key, value] pairs. Returns an ErrUnhashable if a key in an array of pairs is
+// itself an object or array.
+func (hm *HashMap) UnmarshalJSON(data []byte) error {
+	t := (*HashMap)(nil).Transient()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	ok, err := decodeJSONKVs(dec, func(key, val interface{}) error {
+		_, err := t.TrySet(key, val)
+		return err
+	},
+	)
+	if err == nil && ok {
+		*hm = *t.Persistent()
+	}
+	return err
+}
+
+// MarshalJSON implements the json.Marshaler interface. The OrderedMap is
+// marshaled as an object, in insertion order, if all of its keys are strings,
+// or as an array of [key, value] pairs otherwise.
+func (om *OrderedMap) MarshalJSON() ([]byte, error) {
+	return marshalJSONKVs(om)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, replacing the
+// OrderedMap with the key/value pairs of a JSON object, or of a JSON array of
+// [key, value] pairs, in the order they appear. Returns an ErrUnhashable if a
+// key in an array of pairs is itself an object or array.
+func (om *OrderedMap) UnmarshalJSON(data []byte) error {
+	nom := &OrderedMap{NewHashMap(), NewSortedMap(compareUint64s), 0}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	ok, err := decodeJSONKVs(dec, func(key, val interface{}) error {
+		if _, err := tryHash(key); err != nil {
+			return err
+		}
+		nom, _ = nom.Set(key, val)
+		return nil
+	},
+	)
+	if err == nil && ok {
+		*om = *nom
+	}
+	return err
+}
+
+// MarshalJSON implements the json.Marshaler interface, marshaling the SortedSet
+// as an array, in ascending order. There is no UnmarshalJSON, since a SortedSet
+// needs its CompareFn.
+func (set *SortedSet) MarshalJSON() ([]byte, error) {
+	return marshalJSONArray(set)
+}
+
+// MarshalJSON implements the json.Marshaler interface. The SortedMap is
+// marshaled as an object, in ascending key order, if all of its keys are
+// strings, or as an array of [key, value] pairs otherwise. There is no
+// UnmarshalJSON, since a SortedMap needs its CompareFn.
+func (sm *SortedMap) MarshalJSON() ([]byte, error) {
+	return marshalJSONKVs(sm)
+}
+
+// MarshalJSON implements the json.Marshaler interface, marshaling the Heap as
+// an array, smallest first. There is no UnmarshalJSON, since a Heap needs its
+// CompareFn.
+func (h *Heap) MarshalJSON() ([]byte, error) {
+	return marshalJSONArray(h)
+}
+
+// MarshalJSON implements the json.Marshaler interface, marshaling the Lazy as
+// an array. The whole Lazy is evaluated to do so. If it ends with an error that
+// error is returned.
+func (l *Lazy) MarshalJSON() ([]byte, error) {
+	return marshalJSONArray(l)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, making the Lazy
+// yield the elements of a JSON array, or nothing if given null. The Lazy must
+// not have been evaluated yet, which is always the case for one being created
+// by encoding/json.
+func (l *Lazy) UnmarshalJSON(data []byte) error {
+	var els []interface{}
+	if err := json.Unmarshal(data, &els); err != nil {
+		return err
+	}
+	l.t, l.et = nil, toLazyThunk(context.Background(), NewList(els...))
+	return nil
+}
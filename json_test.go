@@ -0,0 +1,241 @@
+package seq
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Marshals v to a JSON string
+func marshalJSONString(t *T, v interface{}) string {
+	b, err := json.Marshal(v)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+// Test marshaling each type to JSON
+func TestMarshalJSON(t *T) {
+	assert.Equal(t, `[1,"a",null]`, marshalJSONString(t, NewList(1, "a", nil)))
+	assert.Equal(t, `[1,2,3]`, marshalJSONString(t, NewVector(1, 2, 3)))
+	assert.Equal(t, `[1,2,3]`, marshalJSONString(t, NewQueue(1, 2).Push(3)))
+	assert.Equal(t, `[0,1,2]`, marshalJSONString(t, NewDeque(1, 2).PushFront(0)))
+	assert.Equal(t, `[1,2,3]`, marshalJSONString(t, NewSortedSet(compareInts, 3, 1, 2)))
+	assert.Equal(t, `[1,2,2,3]`, marshalJSONString(t, NewHeap(compareInts, 2, 3, 1, 2)))
+	assert.Equal(t, `[1,2,3]`, marshalJSONString(t, ToLazy(NewList(1, 2, 3))))
+	assert.Equal(t, `[5]`, marshalJSONString(t, NewSet(5)))
+
+	// Nested structures, and empty ones
+	assert.Equal(t,
+		`{"a":[1,{"b":[2]}],"c":[]}`,
+		marshalJSONString(t, NewHashMap(
+			KeyVal("a", NewList(1, NewHashMap(KeyVal("b", NewVector(2))))),
+			KeyVal("c", NewList()),
+		)),
+	)
+
+	// String keys are sorted for HashMaps, and kept in order for OrderedMaps
+	// and SortedMaps
+	hm := NewHashMap(KeyVal("b", 2), KeyVal("a", 1), KeyVal("c", 3))
+	assert.Equal(t, `{"a":1,"b":2,"c":3}`, marshalJSONString(t, hm))
+	om := NewOrderedMap(KeyVal("b", 2), KeyVal("a", 1), KeyVal("c", 3))
+	assert.Equal(t, `{"b":2,"a":1,"c":3}`, marshalJSONString(t, om))
+	compareStrings := func(a, b interface{}) int {
+		return strings.Compare(a.(string), b.(string))
+	}
+	sm := NewSortedMap(compareStrings, KeyVal("b", 2), KeyVal("a", 1))
+	assert.Equal(t, `{"a":1,"b":2}`, marshalJSONString(t, sm))
+	assert.Equal(t, `{}`, marshalJSONString(t, NewHashMap()))
+	assert.Equal(t,
+		`[[],[],{},{},[[1,[]]]]`,
+		marshalJSONString(t, NewVector(
+			NewList(), NewSet(), NewHashMap(), (*OrderedMap)(nil),
+			NewHashMap(KeyVal(1, NewQueue())),
+		)),
+	)
+
+	// Any other keys make an array of pairs
+	assert.Equal(t, `[[1,"a"]]`, marshalJSONString(t, NewHashMap(KeyVal(1, "a"))))
+	om, _ = om.Set(1, "x")
+	assert.Equal(t, `[["b",2],["a",1],["c",3],[1,"x"]]`, marshalJSONString(t, om))
+	sm = NewSortedMap(compareInts, KeyVal(2, "b"), KeyVal(1, "a"))
+	assert.Equal(t, `[[1,"a"],[2,"b"]]`, marshalJSONString(t, sm))
+
+	// A Lazy ending in an error returns it
+	errBoom := errors.New("boom")
+	l := NewLazyErr(func() (interface{}, ErrThunk, bool, error) {
+		return nil, nil, false, errBoom
+	})
+	_, err := json.Marshal(l)
+	assert.Equal(t, true, errors.Is(err, errBoom))
+	l2 := NewLazyErr(func() (interface{}, ErrThunk, bool, error) {
+		return 1, func() (interface{}, ErrThunk, bool, error) {
+			return nil, nil, false, errBoom
+		}, true, nil
+	})
+	_, err = json.Marshal(l2)
+	assert.Equal(t, true, errors.Is(err, errBoom))
+
+	// Elements which can't be marshaled return an error
+	_, err = json.Marshal(NewVector(1, make(chan int)))
+	assert.NotNil(t, err)
+}
+
+// Test unmarshaling JSON into each type which supports it
+func TestUnmarshalJSON(t *T) {
+	var doc struct {
+		L  *List
+		V  *Vector
+		Q  *Queue
+		D  *Deque
+		S  *Set
+		HM *HashMap
+		OM *OrderedMap
+		Lz *Lazy
+		N  *List
+	}
+	err := json.Unmarshal([]byte(`{
+		"L": [1, "a", [2]],
+		"V": [1, 2],
+		"Q": [1, 2],
+		"D": [1, 2],
+		"S": [1, "a", 1],
+		"HM": {"a": 1, "b": {"c": 2}},
+		"OM": {"b": 1, "a": 2, "c": 3},
+		"Lz": [1, 2],
+		"N": null
+	}`), &doc)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []interface{}{1.0, "a", []interface{}{2.0}}, ToSlice(doc.L))
+	assert.Equal(t, []interface{}{1.0, 2.0}, ToSlice(doc.V))
+	assert.Equal(t, []interface{}{1.0, 2.0}, ToSlice(doc.Q))
+	assert.Equal(t, []interface{}{1.0, 2.0}, ToSlice(doc.D))
+	assert.Equal(t, true, doc.S.Equal(NewSet(1.0, "a")))
+	assert.Equal(t, uint64(2), doc.HM.Size())
+	b, _ := doc.HM.Get("b")
+	assert.Equal(t, map[string]interface{}{"c": 2.0}, b)
+	assert.Equal(t, true, doc.OM.Equal(NewOrderedMap(
+		KeyVal("b", 1.0), KeyVal("a", 2.0), KeyVal("c", 3.0),
+	)))
+	assert.Equal(t, []interface{}{1.0, 2.0}, ToSlice(doc.Lz))
+	assert.Nil(t, doc.N)
+
+	// Maps can be unmarshaled from arrays of pairs, in order for OrderedMaps
+	var hm HashMap
+	assert.Nil(t, json.Unmarshal([]byte(`[[1, "a"], ["b", 2]]`), &hm))
+	assert.Equal(t, true, hm.Equal(NewHashMap(KeyVal(1.0, "a"), KeyVal("b", 2.0))))
+	var om OrderedMap
+	assert.Nil(t, json.Unmarshal([]byte(`[[2, "a"], [1, "b"]]`), &om))
+	assert.Equal(t, true, om.Equal(NewOrderedMap(KeyVal(2.0, "a"), KeyVal(1.0, "b"))))
+	assert.Nil(t, json.Unmarshal([]byte(`{}`), &om))
+	assert.Equal(t, uint64(0), om.Size())
+
+	// Empty arrays make each type empty, and each acts like its nil value
+	var empty struct {
+		V *Vector
+		Q *Queue
+		D *Deque
+		S *Set
+	}
+	err = json.Unmarshal([]byte(`{"V": [], "Q": [], "D": [], "S": []}`), &empty)
+	assert.Nil(t, err)
+	for _, s := range []Seq{empty.V, empty.Q, empty.D, empty.S} {
+		assert.Equal(t, uint64(0), Size(s))
+		assert.Equal(t, 0, len(ToSlice(s)))
+		_, _, ok := s.FirstRest()
+		assert.Equal(t, false, ok)
+		assert.Equal(t, `[]`, marshalJSONString(t, s))
+	}
+	assert.Equal(t, true, empty.V.Equal(NewVector()))
+	assert.Equal(t, true, ToVector(NewList(1, 2, 3)).Equal(empty.V.Append(1).Append(2).Append(3)))
+	assert.Equal(t, true, empty.Q.Equal(NewQueue()))
+	assert.Equal(t, true, NewQueue(1, 2).Equal(empty.Q.Push(1).Push(2)))
+	assert.Equal(t, true, empty.D.Equal(NewDeque()))
+	assert.Equal(t, true, NewDeque(0, 1).Equal(empty.D.PushBack(1).PushFront(0)))
+	assert.Equal(t, true, empty.S.Equal(NewSet()))
+	assert.Equal(t, true, NewSet(1, 2).Equal(empty.S.Union(NewSet(1, 2))))
+	set1, _ := empty.S.SetVal(1)
+	assert.Equal(t, true, NewSet(1).Equal(set1))
+
+	// A Lazy can't be left nil, so null makes it empty
+	var lz Lazy
+	assert.Nil(t, json.Unmarshal([]byte(`null`), &lz))
+	assert.Equal(t, uint64(0), Size(&lz))
+}
+
+// Test that values which can't be unmarshaled return errors
+func TestUnmarshalJSONErrors(t *T) {
+	var set Set
+	err := json.Unmarshal([]byte(`[[1]]`), &set)
+	assert.Equal(t, ErrUnhashable{reflect.TypeOf([]interface{}{})}, err)
+	var hm HashMap
+	err = json.Unmarshal([]byte(`[[[1], 2]]`), &hm)
+	assert.Equal(t, ErrUnhashable{reflect.TypeOf([]interface{}{})}, err)
+	var om OrderedMap
+	err = json.Unmarshal([]byte(`[[{}, 2]]`), &om)
+	assert.Equal(t, ErrUnhashable{reflect.TypeOf(map[string]interface{}{})}, err)
+
+	assert.NotNil(t, json.Unmarshal([]byte(`[[1, 2, 3]]`), &hm))
+	assert.NotNil(t, json.Unmarshal([]byte(`"a"`), &hm))
+	var l List
+	assert.NotNil(t, json.Unmarshal([]byte(`{"a": 1}`), &l))
+
+	// An empty List can only be nil, which encoding/json can't unmarshal into
+	assert.Equal(t, ErrEmptyJSONList, json.Unmarshal([]byte(`[]`), &l))
+	var doc struct{ L *List }
+	err = json.Unmarshal([]byte(`{"L": []}`), &doc)
+	assert.Equal(t, ErrEmptyJSONList, err)
+}
+
+// Test decoding nested JSON into nested HashMaps and Lists
+func TestDecodeJSON(t *T) {
+	dec := json.NewDecoder(strings.NewReader(`
+		{"a": [1, {"b": []}, [true, null]], "c": "d"}
+		[]
+		5
+	`))
+	dec.UseNumber()
+
+	v, err := DecodeJSON(dec)
+	assert.Nil(t, err)
+	expected := NewHashMap(
+		KeyVal("a", NewList(
+			json.Number("1"),
+			NewHashMap(KeyVal("b", (*List)(nil))),
+			NewList(true, nil),
+		)),
+		KeyVal("c", "d"),
+	)
+	assert.Equal(t, true, expected.Equal(v))
+
+	v, err = DecodeJSON(dec)
+	assert.Nil(t, err)
+	assert.Equal(t, (*List)(nil), v)
+
+	v, err = DecodeJSON(dec)
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("5"), v)
+
+	// Sets can hold what DecodeJSON builds, unlike what encoding/json builds
+	dec = json.NewDecoder(strings.NewReader(`[[1], {"a": 1}, [1]]`))
+	v, err = DecodeJSON(dec)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), ToSet(v.(Seq)).Size())
+
+	// A round trip through JSON keeps nested structures
+	b, err := json.Marshal(expected)
+	assert.Nil(t, err)
+	v, err = DecodeJSON(json.NewDecoder(strings.NewReader(string(b))))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), Size(v.(Seq)))
+	inner, ok := GetIn(v.(Seq), []interface{}{"a", 2})
+	assert.Equal(t, true, ok)
+	assert.Equal(t, true, NewList(true, nil).Equal(inner))
+
+	_, err = DecodeJSON(json.NewDecoder(strings.NewReader(`[1,`)))
+	assert.NotNil(t, err)
+}
@@ -1,49 +1,96 @@
 package seq
 
+import (
+	"context"
+	"sync"
+)
+
 // Lazy is an implementation of a Seq which only actually evaluates its contents
 // as those contents become needed. Lazys can be chained together, so if you
 // have three steps in a pipeline there aren't two intermediate Seqs created,
 // only the final resulting one. Lazys are also thread-safe, so multiple
 // routines can interact with the same Lazy pointer at the same time but the
 // contents will only be evalutated once.
+//
+// Each element is evaluated on the go-routine which first asks for it; Lazy
+// never spawns go-routines of its own. Any other go-routines asking for the
+// same element at the same time will wait for that evaluation to finish.
+//
+// A Lazy created with NewLazyErr can end because of an error rather than
+// because it ran out of elements, see ErrSeq.
 type Lazy struct {
+	once sync.Once
+
+	// Only one of these will be set
+	t  Thunk
+	et ErrThunk
+
 	this interface{}
 	next *Lazy
 	ok   bool
-	ch   chan struct{}
+	err  error
 }
 
 // NewLazy returns a Lazy around the Given Thunk
 func NewLazy(t Thunk) *Lazy {
-	l := &Lazy{ch: make(chan struct{})}
-	go func() {
-		l.ch <- struct{}{}
-		el, next, ok := t()
-		l.this = el
-		l.next = NewLazy(next)
-		l.ok = ok
-		close(l.ch)
-	}()
-	return l
+	return &Lazy{t: t}
 }
 
-// FirstRest is an implementation of FirstRest for Seq interface. Completes in
-// O(1) time.
+// NewLazyErr returns a Lazy around the given ErrThunk. If the ErrThunk returns
+// an error the Lazy will end at that point, and that error will be returned by
+// the Lazy's Err method.
+func NewLazyErr(t ErrThunk) *Lazy {
+	return &Lazy{et: t}
+}
+
+// Runs the Lazy's Thunk and stores its results. Must only be called through
+// once.
+func (l *Lazy) eval() {
+	if l.t != nil {
+		el, next, ok := l.t()
+		l.t = nil // let the thunk, and whatever it refers to, be collected
+		l.this, l.ok = el, ok
+		if ok {
+			l.next = NewLazy(next)
+		}
+		return
+	}
+
+	el, next, ok, err := l.et()
+	l.et = nil
+	if err != nil {
+		l.err = err
+		return
+	}
+	l.this, l.ok = el, ok
+	if ok {
+		l.next = NewLazyErr(next)
+	}
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. The first call
+// to FirstRest evaluates the Lazy's Thunk, all subsequent calls return the
+// cached result in O(1) time.
 func (l *Lazy) FirstRest() (interface{}, Seq, bool) {
 	if l == nil {
 		return nil, l, false
 	}
 
-	// Reading from the channel tells the Lazy to populate the data and prepare
-	// the next item in the seq, it closes the channel when it's done that.
-	if _, ok := <-l.ch; ok {
-		<-l.ch
-	}
-
+	l.once.Do(l.eval)
 	if l.ok {
 		return l.this, l.next, true
 	}
-	return nil, nil, false
+	return nil, l, false
+}
+
+// Err is an implementation of Err for the ErrSeq interface. If the Lazy hasn't
+// been evaluated yet this will evaluate it.
+func (l *Lazy) Err() error {
+	if l == nil {
+		return nil
+	}
+	l.once.Do(l.eval)
+	return l.err
 }
 
 // String is an implementation of String for Stringer
@@ -56,80 +103,195 @@ func (l *Lazy) String() string {
 // was actually empty (true indicates it yielded results).
 type Thunk func() (interface{}, Thunk, bool)
 
-func mapThunk(fn func(interface{}) interface{}, s Seq) Thunk {
-	return func() (interface{}, Thunk, bool) {
+// ErrThunk is like a Thunk, but can also return an error. If the error is
+// non-nil the Lazy built from it ends there, regardless of the other return
+// values, and the error can be retrieved with Err.
+type ErrThunk func() (interface{}, ErrThunk, bool, error)
+
+// ErrSeq is a Seq which can end because of an error, rather than because it
+// has run out of elements. Once FirstRest has returned false, calling Err on
+// the same ErrSeq returns the error which ended it, or nil if it ended
+// cleanly.
+type ErrSeq interface {
+	Seq
+	Err() error
+}
+
+// Err returns the error which ended the given Seq, if it is an ErrSeq, or nil.
+// It should be called on the Seq which FirstRest has returned false for.
+func Err(s Seq) error {
+	if es, ok := s.(ErrSeq); ok {
+		return es.Err()
+	}
+	return nil
+}
+
+// Wraps a Thunk as an ErrThunk which never returns an error
+func thunkErr(t Thunk) ErrThunk {
+	return func() (interface{}, ErrThunk, bool, error) {
+		el, next, ok := t()
+		if !ok {
+			return nil, nil, false, nil
+		}
+		return el, thunkErr(next), true, nil
+	}
+}
+
+// Wraps an ErrThunk so that it, and all the ErrThunks following it, return the
+// context's error instead of being called once the context is done
+func contextThunk(ctx context.Context, t ErrThunk) ErrThunk {
+	return func() (interface{}, ErrThunk, bool, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, false, err
+		}
+		el, next, ok, err := t()
+		if err != nil || !ok {
+			return nil, nil, false, err
+		}
+		return el, contextThunk(ctx, next), true, nil
+	}
+}
+
+// NewLazyContext returns a Lazy around the given Thunk which stops once the
+// given context is done. Once it is, evaluating the Lazy any further will not
+// call any more Thunks, and it will end with the context's error (see ErrSeq).
+// Elements which were evaluated before then remain available.
+func NewLazyContext(ctx context.Context, t Thunk) *Lazy {
+	return NewLazyErrContext(ctx, thunkErr(t))
+}
+
+// NewLazyErrContext is like NewLazyContext, but for an ErrThunk
+func NewLazyErrContext(ctx context.Context, t ErrThunk) *Lazy {
+	return NewLazyErr(contextThunk(ctx, t))
+}
+
+func mapThunk(ctx context.Context, fn func(interface{}) interface{}, s Seq) ErrThunk {
+	return func() (interface{}, ErrThunk, bool, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, false, err
+		}
 		el, ns, ok := s.FirstRest()
 		if !ok {
-			return nil, nil, false
+			return nil, nil, false, Err(ns)
 		}
 
-		return fn(el), mapThunk(fn, ns), true
+		return fn(el), mapThunk(ctx, fn, ns), true, nil
 	}
 }
 
-// LMap is a lazy implementation of Map
+// LMap is a lazy implementation of Map. If the given Seq is an ErrSeq which
+// ends with an error the returned Seq will end with the same error.
 func LMap(fn func(interface{}) interface{}, s Seq) Seq {
-	return NewLazy(mapThunk(fn, s))
+	return LMapContext(context.Background(), fn, s)
+}
+
+// LMapContext is like LMap, but the returned Seq stops once the given context
+// is done, ending with the context's error. fn won't be called, nor any
+// more elements read from s, after that point.
+func LMapContext(ctx context.Context, fn func(interface{}) interface{}, s Seq) Seq {
+	return NewLazyErr(mapThunk(ctx, fn, s))
 }
 
-func filterThunk(fn func(interface{}) bool, s Seq) Thunk {
-	return func() (interface{}, Thunk, bool) {
+func filterThunk(ctx context.Context, fn func(interface{}) bool, s Seq) ErrThunk {
+	return func() (interface{}, ErrThunk, bool, error) {
 		for {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, false, err
+			}
 			el, ns, ok := s.FirstRest()
 			if !ok {
-				return nil, nil, false
+				return nil, nil, false, Err(ns)
 			}
 
 			if keep := fn(el); keep {
-				return el, filterThunk(fn, ns), true
+				return el, filterThunk(ctx, fn, ns), true, nil
 			}
 			s = ns
 		}
 	}
 }
 
-// LFilter is a lazy implementation of Filter
+// LFilter is a lazy implementation of Filter. If the given Seq is an ErrSeq
+// which ends with an error the returned Seq will end with the same error.
 func LFilter(fn func(interface{}) bool, s Seq) Seq {
-	return NewLazy(filterThunk(fn, s))
+	return LFilterContext(context.Background(), fn, s)
+}
+
+// LFilterContext is like LFilter, but the returned Seq stops once the given
+// context is done, ending with the context's error. fn won't be called, nor
+// any more elements read from s, after that point.
+func LFilterContext(ctx context.Context, fn func(interface{}) bool, s Seq) Seq {
+	return NewLazyErr(filterThunk(ctx, fn, s))
 }
 
-func takeThunk(n uint64, s Seq) Thunk {
-	return func() (interface{}, Thunk, bool) {
+func takeThunk(ctx context.Context, n uint64, s Seq) ErrThunk {
+	return func() (interface{}, ErrThunk, bool, error) {
+		if n == 0 {
+			return nil, nil, false, nil
+		} else if err := ctx.Err(); err != nil {
+			return nil, nil, false, err
+		}
 		el, ns, ok := s.FirstRest()
-		if !ok || n == 0 {
-			return nil, nil, false
+		if !ok {
+			return nil, nil, false, Err(ns)
 		}
-		return el, takeThunk(n-1, ns), true
+		return el, takeThunk(ctx, n-1, ns), true, nil
 	}
 }
 
-// LTake is a lazy implementation of Take
+// LTake is a lazy implementation of Take. If the given Seq is an ErrSeq which
+// ends with an error within the first n elements the returned Seq will end
+// with the same error.
 func LTake(n uint64, s Seq) Seq {
-	return NewLazy(takeThunk(n, s))
+	return LTakeContext(context.Background(), n, s)
+}
+
+// LTakeContext is like LTake, but the returned Seq stops once the given context
+// is done, ending with the context's error. No more elements will be read from
+// s after that point.
+func LTakeContext(ctx context.Context, n uint64, s Seq) Seq {
+	return NewLazyErr(takeThunk(ctx, n, s))
 }
 
-func takeWhileThunk(fn func(interface{}) bool, s Seq) Thunk {
-	return func() (interface{}, Thunk, bool) {
+func takeWhileThunk(ctx context.Context, fn func(interface{}) bool, s Seq) ErrThunk {
+	return func() (interface{}, ErrThunk, bool, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, false, err
+		}
 		el, ns, ok := s.FirstRest()
-		if !ok || !fn(el) {
-			return nil, nil, false
+		if !ok {
+			return nil, nil, false, Err(ns)
+		} else if !fn(el) {
+			return nil, nil, false, nil
 		}
-		return el, takeWhileThunk(fn, ns), true
+		return el, takeWhileThunk(ctx, fn, ns), true, nil
 	}
 }
 
-// LTakeWhile is a lazy implementation of TakeWhile
+// LTakeWhile is a lazy implementation of TakeWhile. If the given Seq is an
+// ErrSeq which ends with an error before fn returns false the returned Seq
+// will end with the same error.
 func LTakeWhile(fn func(interface{}) bool, s Seq) Seq {
-	return NewLazy(takeWhileThunk(fn, s))
+	return LTakeWhileContext(context.Background(), fn, s)
+}
+
+// LTakeWhileContext is like LTakeWhile, but the returned Seq stops once the
+// given context is done, ending with the context's error. fn won't be called,
+// nor any more elements read from s, after that point.
+func LTakeWhileContext(ctx context.Context, fn func(interface{}) bool, s Seq) Seq {
+	return NewLazyErr(takeWhileThunk(ctx, fn, s))
 }
 
-func toLazyThunk(s Seq) Thunk {
-	return func() (interface{}, Thunk, bool) {
+func toLazyThunk(ctx context.Context, s Seq) ErrThunk {
+	return func() (interface{}, ErrThunk, bool, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, false, err
+		}
 		el, ns, ok := s.FirstRest()
 		if !ok {
-			return nil, nil, false
+			return nil, nil, false, Err(ns)
 		}
-		return el, toLazyThunk(ns), true
+		return el, toLazyThunk(ctx, ns), true, nil
 	}
 }
 
@@ -137,5 +299,12 @@ func toLazyThunk(s Seq) Thunk {
 // useful for other implementations where FirstRest might be costly and the same
 // Seq needs to be iterated over many times.
 func ToLazy(s Seq) *Lazy {
-	return NewLazy(toLazyThunk(s))
+	return ToLazyContext(context.Background(), s)
+}
+
+// ToLazyContext is like ToLazy, but the returned Lazy stops once the given
+// context is done, ending with the context's error. No more elements will be
+// read from s after that point.
+func ToLazyContext(ctx context.Context, s Seq) *Lazy {
+	return NewLazyErr(toLazyThunk(ctx, s))
 }
@@ -1,6 +1,11 @@
 package seq
 
 import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	. "testing"
 	"time"
 
@@ -44,3 +49,222 @@ func TestToLazy(t *T) {
 	ll := ToLazy(l)
 	assert.Equal(t, intl, ToSlice(ll))
 }
+
+// Test that each Thunk is only called once, even when many routines are
+// reading from the same Lazy at the same time
+func TestLazyOnce(t *T) {
+	var calls int64
+	var thunk func(int) Thunk
+	thunk = func(i int) Thunk {
+		return func() (interface{}, Thunk, bool) {
+			atomic.AddInt64(&calls, 1)
+			if i == 100 {
+				return nil, nil, false
+			}
+			return i, thunk(i + 1), true
+		}
+	}
+	l := NewLazy(thunk(0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, 100, len(ToSlice(l)))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(101), atomic.LoadInt64(&calls))
+}
+
+// Test that reading from Lazys, whether partially or fully, doesn't leave any
+// go-routines behind
+func TestLazyNoGoroutines(t *T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		l := LMap(func(el interface{}) interface{} { return el }, Numbers())
+		ToSlice(LTake(10, l))
+		ToSlice(LTake(20, ToLazy(NewList(1, 2, 3))))
+	}
+	assert.Equal(t, before, runtime.NumGoroutine())
+}
+
+func BenchmarkLazyPipeline(b *B) {
+	l := NewList(intsTo(1000)...)
+	fn := func(el interface{}) interface{} { return el }
+	pred := func(el interface{}) bool { return el.(int)%2 == 0 }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ToSlice(LFilter(pred, LMap(fn, LMap(fn, l))))
+	}
+}
+
+func BenchmarkLazyPartial(b *B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ToSlice(LTake(10, Numbers()))
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(runtime.NumGoroutine()), "goroutines")
+}
+
+// Returns an ErrThunk which yields the integers [0, n) and then ends with err
+func errThunk(i, n int, err error) ErrThunk {
+	return func() (interface{}, ErrThunk, bool, error) {
+		if i == n {
+			return nil, nil, false, err
+		}
+		return i, errThunk(i+1, n, err), true, nil
+	}
+}
+
+// Test that Lazys made from ErrThunks end with their error, and that consumers
+// can retrieve it
+func TestLazyErr(t *T) {
+	errBad := errors.New("bad")
+	l := NewLazyErr(errThunk(0, 3, errBad))
+
+	ints, err := ToSliceErr(l)
+	assert.Equal(t, []interface{}{0, 1, 2}, ints)
+	assert.Equal(t, errBad, err)
+
+	// Reading again gives the same, cached, result
+	ints, err = ToSliceErr(l)
+	assert.Equal(t, []interface{}{0, 1, 2}, ints)
+	assert.Equal(t, errBad, err)
+	assert.Equal(t, []interface{}{0, 1, 2}, ToSlice(l))
+
+	sum := func(acc, el interface{}) (interface{}, bool) {
+		return acc.(int) + el.(int), false
+	}
+	acc, err := ReduceErr(sum, 0, l)
+	assert.Equal(t, 3, acc)
+	assert.Equal(t, errBad, err)
+
+	// Stopping the reduce before the error means no error
+	sumStop := func(acc, el interface{}) (interface{}, bool) {
+		return acc.(int) + el.(int), el.(int) == 1
+	}
+	acc, err = ReduceErr(sumStop, 0, l)
+	assert.Equal(t, 1, acc)
+	assert.Equal(t, nil, err)
+
+	// The error is on the node where the Lazy ended
+	_, rest, _ := l.FirstRest()
+	assert.Equal(t, nil, Err(rest))
+	assert.Equal(t, errBad, Err(Drop(3, l)))
+
+	// Clean ends, and non-ErrSeqs, have no error
+	ints, err = ToSliceErr(NewLazyErr(errThunk(0, 3, nil)))
+	assert.Equal(t, []interface{}{0, 1, 2}, ints)
+	assert.Equal(t, nil, err)
+	ints, err = ToSliceErr(NewList(0, 1))
+	assert.Equal(t, []interface{}{0, 1}, ints)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, Err(NewLazy(numberThunk(0))))
+}
+
+// Test that the lazy functions pass through the error of the Seq they're
+// reading from
+func TestLazyErrPropagation(t *T) {
+	errBad := errors.New("bad")
+	l := NewLazyErr(errThunk(0, 4, errBad))
+	inc := func(el interface{}) interface{} { return el.(int) + 1 }
+	even := func(el interface{}) bool { return el.(int)%2 == 0 }
+	small := func(el interface{}) bool { return el.(int) < 10 }
+
+	assertErr := func(exp []interface{}, expErr error, s Seq) {
+		ints, err := ToSliceErr(s)
+		assert.Equal(t, exp, ints)
+		assert.Equal(t, expErr, err)
+	}
+
+	assertErr([]interface{}{1, 2, 3, 4}, errBad, LMap(inc, l))
+	assertErr([]interface{}{0, 2}, errBad, LFilter(even, l))
+	assertErr([]interface{}{0, 1, 2, 3}, errBad, LTake(10, l))
+	assertErr([]interface{}{0, 1}, nil, LTake(2, l))
+	assertErr([]interface{}{0, 1, 2, 3}, errBad, LTakeWhile(small, l))
+	assertErr([]interface{}{0}, nil, LTakeWhile(even, l))
+	assertErr([]interface{}{0, 1, 2, 3}, errBad, ToLazy(l))
+	assertErr([]interface{}{2, 4}, errBad, LFilter(even, LMap(inc, l)))
+}
+
+// Test that a Lazy made with a context stops calling Thunks once the context is
+// cancelled
+func TestLazyContext(t *T) {
+	var calls int
+	var thunk func(int) Thunk
+	thunk = func(i int) Thunk {
+		return func() (interface{}, Thunk, bool) {
+			calls++
+			return i, thunk(i + 1), true
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := NewLazyContext(ctx, thunk(0))
+	assert.Equal(t, []interface{}{0, 1, 2}, ToSlice(Take(3, l)))
+	assert.Equal(t, 3, calls)
+
+	cancel()
+	ints, err := ToSliceErr(l)
+	assert.Equal(t, []interface{}{0, 1, 2}, ints)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 3, calls)
+}
+
+// Test that the context-aware lazy functions stop reading from their Seq, and
+// calling their function, once the context is cancelled
+func TestLazyFnContext(t *T) {
+	// Each mkSeq is given a function to use as a map or filter function, which
+	// cancels the context after its fifth call
+	assertCancelled := func(exp []interface{}, mkSeq func(context.Context, func(interface{}) bool) Seq) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var calls int
+		fn := func(el interface{}) bool {
+			if calls++; calls == 5 {
+				cancel()
+			}
+			return el.(int)%2 == 0
+		}
+
+		ints, err := ToSliceErr(mkSeq(ctx, fn))
+		assert.Equal(t, exp, ints)
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, 5, calls)
+	}
+
+	// Turns a filter function into a map function which doesn't change the
+	// element
+	mapFn := func(fn func(interface{}) bool) func(interface{}) interface{} {
+		return func(el interface{}) interface{} {
+			fn(el)
+			return el
+		}
+	}
+	always := func(interface{}) bool { return true }
+
+	assertCancelled([]interface{}{0, 1, 2, 3, 4}, func(ctx context.Context, fn func(interface{}) bool) Seq {
+		return LMapContext(ctx, mapFn(fn), Numbers())
+	})
+	assertCancelled([]interface{}{0, 2, 4}, func(ctx context.Context, fn func(interface{}) bool) Seq {
+		return LFilterContext(ctx, fn, Numbers())
+	})
+	assertCancelled([]interface{}{0, 1, 2, 3, 4}, func(ctx context.Context, fn func(interface{}) bool) Seq {
+		return LTakeWhileContext(ctx, always, LMap(mapFn(fn), Numbers()))
+	})
+	assertCancelled([]interface{}{0, 1, 2, 3, 4}, func(ctx context.Context, fn func(interface{}) bool) Seq {
+		return LTakeContext(ctx, 100, LMap(mapFn(fn), Numbers()))
+	})
+	assertCancelled([]interface{}{0, 1, 2, 3, 4}, func(ctx context.Context, fn func(interface{}) bool) Seq {
+		return ToLazyContext(ctx, LMap(mapFn(fn), Numbers()))
+	})
+
+	// Cancellation of an inner context is passed through outer lazy functions
+	assertCancelled([]interface{}{0, 1, 2, 3, 4}, func(ctx context.Context, fn func(interface{}) bool) Seq {
+		return LFilter(always, LMapContext(ctx, mapFn(fn), Numbers()))
+	})
+}
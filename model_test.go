@@ -0,0 +1,667 @@
+package seq
+
+import (
+	"reflect"
+	"sort"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file holds a model-based test harness for the persistent types. Each
+// fuzz test reads a sequence of operations out of its input and applies them
+// both to one of the types and to a plain go slice or map (the model) which it
+// should always match. Every version produced along the way is kept, and at
+// the end each one is checked against the model it had when it was made, so
+// any change made to an older version shows up as a failure.
+//
+// Run with `go test -fuzz FuzzSetModel` (or any of the others) to search for
+// new failing inputs, otherwise only the seed inputs are used. Each input can
+// run hundreds of operations, so minimizing new inputs is slow; passing
+// something like `-fuzzminimizetime 100x` keeps the fuzzer moving.
+
+// The most operations a single input will be used for
+const modelMaxSteps = 200
+
+// opReader turns fuzz input into a stream of small numbers, for choosing
+// operations and their arguments
+type opReader struct {
+	b []byte
+}
+
+func (r *opReader) done() bool {
+	return len(r.b) == 0
+}
+
+// Returns a number in [0, n). Returns 0 once the input has run out.
+func (r *opReader) next(n int) int {
+	if len(r.b) == 0 {
+		return 0
+	}
+	i := int(r.b[0]) % n
+	r.b = r.b[1:]
+	return i
+}
+
+// modelVersion is a single version of a collection, along with the model it
+// should match
+type modelVersion[C, M any] struct {
+	c C
+	m M
+}
+
+// runModel applies operations to the collection c and its model m, using step,
+// until the input runs out. Each step must return a new model rather than
+// change the one it was given. check asserts that a collection matches a
+// model, and is called on the current version after every step and on every
+// version once all steps are done.
+func runModel[C, M any](
+	t *T, data []byte, c C, m M,
+	step func(r *opReader, c C, m M) (C, M),
+	check func(t *T, c C, m M),
+) {
+	r := &opReader{data}
+	versions := []modelVersion[C, M]{{c, m}}
+	for i := 0; i < modelMaxSteps && !r.done(); i++ {
+		c, m = step(r, c, m)
+		check(t, c, m)
+		versions = append(versions, modelVersion[C, M]{c, m})
+	}
+	for _, v := range versions {
+		check(t, v.c, v.m)
+	}
+}
+
+// Returns a copy of the slice with room for one more element
+func cloneSlice(s []interface{}) []interface{} {
+	return append(make([]interface{}, 0, len(s)+1), s...)
+}
+
+// Returns a copy of the map
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	m2 := make(map[K]V, len(m))
+	for k, v := range m {
+		m2[k] = v
+	}
+	return m2
+}
+
+// Asserts that the Seq holds the elements of the slice, in the same order
+func assertSeqSlice(t *T, m []interface{}, s Seq) {
+	got := ToSlice(s)
+	if !assert.Equal(t, len(m), len(got)) {
+		return
+	}
+	for i := range m {
+		assert.Equal(t, m[i], got[i])
+	}
+}
+
+func seedModel(f *F) {
+	f.Add([]byte{})
+	f.Add([]byte{0, 1, 0, 2, 1, 3, 2, 4, 3, 5, 4, 6, 5, 7, 6, 8, 7, 9})
+	seed := make([]byte, 400)
+	for i := range seed {
+		seed[i] = byte(i*7 + i/3)
+	}
+	f.Add(seed)
+}
+
+func FuzzListModel(f *F) {
+	seedModel(f)
+	f.Fuzz(func(t *T, data []byte) {
+		step := func(r *opReader, l *List, m []interface{}) (*List, []interface{}) {
+			switch r.next(6) {
+			case 0:
+				v := r.next(100)
+				return l.Prepend(v), append([]interface{}{v}, m...)
+			case 1:
+				v := r.next(100)
+				return l.Append(v), append(cloneSlice(m), v)
+			case 2:
+				vals := []interface{}{r.next(100), r.next(100)}
+				return l.PrependSeq(NewList(vals...)), append(vals, m...)
+			case 3:
+				if _, rest, ok := l.FirstRest(); ok {
+					return rest.(*List), m[1:]
+				}
+				return l, m
+			case 4:
+				rev := make([]interface{}, len(m))
+				for i := range m {
+					rev[len(m)-1-i] = m[i]
+				}
+				return Reverse(l).(*List), rev
+			default:
+				tl := l.Transient()
+				m = cloneSlice(m)
+				for n := r.next(4); n > 0; n-- {
+					v := r.next(100)
+					if r.next(2) == 0 {
+						tl.Prepend(v)
+						m = append([]interface{}{v}, m...)
+					} else {
+						tl.Append(v)
+						m = append(m, v)
+					}
+				}
+				return tl.Persistent(), m
+			}
+		}
+		check := func(t *T, l *List, m []interface{}) {
+			assert.Equal(t, uint64(len(m)), Size(l))
+			assertSeqSlice(t, m, l)
+		}
+		runModel(t, data, NewList(), []interface{}{}, step, check)
+	})
+}
+
+func FuzzQueueModel(f *F) {
+	seedModel(f)
+	f.Fuzz(func(t *T, data []byte) {
+		step := func(r *opReader, q *Queue, m []interface{}) (*Queue, []interface{}) {
+			switch r.next(3) {
+			case 0, 1:
+				v := r.next(100)
+				return q.Push(v), append(cloneSlice(m), v)
+			default:
+				if _, nq, ok := q.Pop(); ok {
+					return nq, m[1:]
+				}
+				return q, m
+			}
+		}
+		check := func(t *T, q *Queue, m []interface{}) {
+			assert.Equal(t, uint64(len(m)), q.Size())
+			assertSeqSlice(t, m, q)
+			if el, ok := q.Peek(); ok {
+				assert.Equal(t, m[0], el)
+			}
+		}
+		runModel(t, data, NewQueue(), []interface{}{}, step, check)
+	})
+}
+
+func FuzzDequeModel(f *F) {
+	seedModel(f)
+	f.Fuzz(func(t *T, data []byte) {
+		step := func(r *opReader, d *Deque, m []interface{}) (*Deque, []interface{}) {
+			switch r.next(7) {
+			case 0:
+				v := r.next(100)
+				return d.PushFront(v), append([]interface{}{v}, m...)
+			case 1:
+				v := r.next(100)
+				return d.PushBack(v), append(cloneSlice(m), v)
+			case 2:
+				if _, nd, ok := d.PopFront(); ok {
+					return nd, m[1:]
+				}
+				return d, m
+			case 3:
+				if _, nd, ok := d.PopBack(); ok {
+					return nd, m[:len(m)-1]
+				}
+				return d, m
+			case 4:
+				vals := make([]interface{}, r.next(20))
+				for i := range vals {
+					vals[i] = r.next(100)
+				}
+				if r.next(2) == 0 {
+					return d.Concat(NewDeque(vals...)), append(cloneSlice(m), vals...)
+				}
+				return NewDeque(vals...).Concat(d), append(vals, m...)
+			default:
+				i := r.next(len(m) + 1)
+				l, rest := d.SplitAt(uint64(i))
+				if r.next(2) == 0 {
+					return l, m[:i]
+				}
+				return rest, m[i:]
+			}
+		}
+		check := func(t *T, d *Deque, m []interface{}) {
+			assertDeque(t, m, d)
+		}
+		runModel(t, data, NewDeque(), []interface{}{}, step, check)
+	})
+}
+
+func FuzzVectorModel(f *F) {
+	seedModel(f)
+	f.Fuzz(func(t *T, data []byte) {
+		step := func(r *opReader, v *Vector, m []interface{}) (*Vector, []interface{}) {
+			switch r.next(5) {
+			case 0, 1:
+				el := r.next(100)
+				return v.Append(el), append(cloneSlice(m), el)
+			case 2:
+				if len(m) == 0 {
+					return v, m
+				}
+				i, el := r.next(len(m)), r.next(100)
+				nv, ok := v.Assoc(uint64(i), el)
+				assert.True(t, ok)
+				m = cloneSlice(m)
+				m[i] = el
+				return nv, m
+			case 3:
+				if el, nv, ok := v.Pop(); ok {
+					assert.Equal(t, m[len(m)-1], el)
+					return nv, m[:len(m)-1]
+				}
+				return v, m
+			default:
+				to := r.next(len(m) + 1)
+				from := r.next(to + 1)
+				nv, ok := v.Slice(uint64(from), uint64(to))
+				assert.True(t, ok)
+				return nv, m[from:to]
+			}
+		}
+		check := func(t *T, v *Vector, m []interface{}) {
+			assertVector(t, m, v)
+		}
+		runModel(t, data, NewVector(), []interface{}{}, step, check)
+	})
+}
+
+func FuzzHeapModel(f *F) {
+	seedModel(f)
+	f.Fuzz(func(t *T, data []byte) {
+		// The model is kept sorted, since that's the order the Heap's elements
+		// come out in
+		insert := func(m []interface{}, v int) []interface{} {
+			i := sort.Search(len(m), func(i int) bool { return m[i].(int) > v })
+			m2 := append(cloneSlice(m[:i]), v)
+			return append(m2, m[i:]...)
+		}
+
+		step := func(r *opReader, h *Heap, m []interface{}) (*Heap, []interface{}) {
+			switch r.next(5) {
+			case 0, 1:
+				v := r.next(50)
+				return h.Insert(v), insert(m, v)
+			case 2:
+				if el, nh, ok := h.PopMin(); ok {
+					assert.Equal(t, m[0], el)
+					return nh, m[1:]
+				}
+				return h, m
+			case 3:
+				// Merging with itself, to check that none of the shared nodes
+				// are changed
+				m2 := m
+				for _, v := range m {
+					m2 = insert(m2, v.(int))
+				}
+				return h.Merge(h), m2
+			default:
+				h2 := NewHeap(compareInts)
+				for n := r.next(5); n > 0; n-- {
+					v := r.next(50)
+					h2, m = h2.Insert(v), insert(m, v)
+				}
+				return h.Merge(h2), m
+			}
+		}
+		check := func(t *T, h *Heap, m []interface{}) {
+			assert.Equal(t, uint64(len(m)), assertSaneHeap(t, h.root))
+			assert.Equal(t, uint64(len(m)), h.Size())
+			if el, ok := h.PeekMin(); assert.Equal(t, len(m) > 0, ok) && ok {
+				assert.Equal(t, m[0], el)
+			}
+			assertSeqSlice(t, m, h)
+		}
+		runModel(t, data, NewHeap(compareInts), []interface{}{}, step, check)
+	})
+}
+
+func FuzzSortedSetModel(f *F) {
+	seedModel(f)
+	f.Fuzz(func(t *T, data []byte) {
+		step := func(r *opReader, set *SortedSet, m map[int]bool) (*SortedSet, map[int]bool) {
+			m = cloneMap(m)
+			switch r.next(4) {
+			case 0, 1:
+				v := r.next(100)
+				var ok bool
+				set, ok = set.SetVal(v)
+				assert.Equal(t, !m[v], ok)
+				m[v] = true
+			case 2:
+				v := r.next(100)
+				var ok bool
+				set, ok = set.DelVal(v)
+				assert.Equal(t, m[v], ok)
+				delete(m, v)
+			default:
+				if el, rest, ok := set.FirstRest(); ok {
+					set = rest.(*SortedSet)
+					delete(m, el.(int))
+				}
+			}
+			return set, m
+		}
+		check := func(t *T, set *SortedSet, m map[int]bool) {
+			assert.Equal(t, uint64(len(m)), assertSaneAVL(t, set.root))
+			assert.Equal(t, uint64(len(m)), set.Size())
+			keys := make([]int, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Ints(keys)
+			vals := make([]interface{}, len(keys))
+			for i := range keys {
+				vals[i] = keys[i]
+			}
+			assertSeqSlice(t, vals, set)
+			if min, ok := set.Min(); assert.Equal(t, len(m) > 0, ok) && ok {
+				assert.Equal(t, vals[0], min)
+			}
+		}
+		runModel(t, data, NewSortedSet(compareInts), map[int]bool{}, step, check)
+	})
+}
+
+func FuzzSetModel(f *F) {
+	seedModel(f)
+	f.Fuzz(func(t *T, data []byte) {
+		randVal := func(r *opReader) interface{} {
+			if v := r.next(60); v >= 50 {
+				return collider(v)
+			} else {
+				return v
+			}
+		}
+		toggle := func(m map[interface{}]bool, v interface{}) {
+			if m[v] {
+				delete(m, v)
+			} else {
+				m[v] = true
+			}
+		}
+
+		step := func(r *opReader, set *Set, m map[interface{}]bool) (*Set, map[interface{}]bool) {
+			m = cloneMap(m)
+			switch r.next(7) {
+			case 0:
+				v := randVal(r)
+				set, _ = set.SetVal(v)
+				m[v] = true
+			case 1:
+				v := randVal(r)
+				set, _ = set.DelVal(v)
+				delete(m, v)
+			case 2:
+				el, rest, ok := set.FirstRest()
+				if ok {
+					set = rest.(*Set)
+					delete(m, el)
+				}
+			case 3, 4:
+				vals := []interface{}{randVal(r), randVal(r), randVal(r)}
+				var s Seq = NewList(vals...)
+				if r.next(2) == 0 {
+					s = NewSet(vals...)
+				}
+				if r.next(2) == 0 {
+					set = set.Union(s)
+					for _, v := range vals {
+						m[v] = true
+					}
+				} else {
+					set = set.Difference(s)
+					for _, v := range vals {
+						delete(m, v)
+					}
+				}
+			case 5:
+				vals := []interface{}{randVal(r), randVal(r), randVal(r)}
+				set = set.SymDifference(NewSet(vals...))
+				for v := range Values(NewSet(vals...)) {
+					toggle(m, v)
+				}
+			default:
+				ts := set.Transient()
+				for n := r.next(8); n > 0; n-- {
+					v := randVal(r)
+					if r.next(2) == 0 {
+						ts.SetVal(v)
+						m[v] = true
+					} else {
+						ts.DelVal(v)
+						delete(m, v)
+					}
+				}
+				set = ts.Persistent()
+			}
+			return set, m
+		}
+		check := func(t *T, set *Set, m map[interface{}]bool) {
+			assertSaneSet(t, set)
+			assert.Equal(t, uint64(len(m)), set.Size())
+			vals := make([]interface{}, 0, len(m))
+			for v := range m {
+				vals = append(vals, v)
+			}
+			assertSeqContentsSet(t, vals, set)
+		}
+		runModel(t, data, NewSet(), map[interface{}]bool{}, step, check)
+	})
+}
+
+func FuzzHashMapModel(f *F) {
+	seedModel(f)
+	f.Fuzz(func(t *T, data []byte) {
+		step := func(r *opReader, hm *HashMap, m map[int]int) (*HashMap, map[int]int) {
+			m = cloneMap(m)
+			switch r.next(7) {
+			case 0:
+				k, v := r.next(50), r.next(100)
+				hm, _ = hm.Set(k, v)
+				m[k] = v
+			case 4:
+				k := r.next(50)
+				inc := func(v interface{}) interface{} { return v.(int) + 1 }
+				if r.next(2) == 0 {
+					hm, _ = hm.Update(k, inc)
+					if _, ok := m[k]; ok {
+						m[k]++
+					}
+				} else {
+					hm = hm.UpdateWithDefault(k, 0, inc)
+					m[k]++
+				}
+			case 5:
+				// Merge with either a new HashMap or another version of this
+				// one, which shares its nodes
+				m2 := map[int]int{}
+				var hm2 *HashMap
+				if r.next(2) == 0 {
+					hm2, m2 = hm, cloneMap(m)
+				}
+				for n := r.next(8); n > 0; n-- {
+					k, v := r.next(50), r.next(100)
+					hm2, _ = hm2.Set(k, v)
+					m2[k] = v
+				}
+				if r.next(2) == 0 {
+					hm = hm.Merge(hm2)
+					for k, v := range m2 {
+						m[k] = v
+					}
+					break
+				}
+				hm = hm.MergeWith(func(v1, v2 interface{}) interface{} {
+					return v1.(int) - v2.(int)
+				}, hm2)
+				for k, v := range m2 {
+					if v1, ok := m[k]; ok {
+						v = v1 - v
+					}
+					m[k] = v
+				}
+			case 6:
+				var keys []interface{}
+				sel := map[int]bool{}
+				for n := r.next(8); n > 0; n-- {
+					k := r.next(50)
+					keys = append(keys, k)
+					sel[k] = true
+				}
+				hm = hm.SelectKeys(keys...)
+				for k := range m {
+					if !sel[k] {
+						delete(m, k)
+					}
+				}
+			case 1:
+				k := r.next(50)
+				hm, _ = hm.Del(k)
+				delete(m, k)
+			case 2:
+				if kv, rest, ok := hm.FirstRestKV(); ok {
+					hm = rest
+					delete(m, kv.Key.(int))
+				}
+			default:
+				th := hm.Transient()
+				for n := r.next(8); n > 0; n-- {
+					k, v := r.next(50), r.next(100)
+					if r.next(2) == 0 {
+						th.Set(k, v)
+						m[k] = v
+					} else {
+						th.Del(k)
+						delete(m, k)
+					}
+				}
+				hm = th.Persistent()
+			}
+			return hm, m
+		}
+		check := func(t *T, hm *HashMap, m map[int]int) {
+			assert.Equal(t, uint64(len(m)), hm.Size())
+			got := map[int]int{}
+			keys, vals := []interface{}{}, []interface{}{}
+			for el := range Values(hm) {
+				kv := el.(*KV)
+				got[kv.Key.(int)] = kv.Val.(int)
+				keys, vals = append(keys, kv.Key), append(vals, kv.Val)
+			}
+			assert.True(t, reflect.DeepEqual(m, got))
+
+			// Keys and Vals are in the same order as the KVs
+			assert.Equal(t, keys, ToSlice(hm.Keys()))
+			assert.Equal(t, vals, ToSlice(hm.Vals()))
+		}
+		runModel(t, data, NewHashMap(), map[int]int{}, step, check)
+	})
+}
+
+func FuzzOrderedMapModel(f *F) {
+	seedModel(f)
+	f.Fuzz(func(t *T, data []byte) {
+		// Returns the index of the KV with the given key, or len(m)
+		find := func(m []*KV, key int) int {
+			i := 0
+			for ; i < len(m) && m[i].Key != key; i++ {
+			}
+			return i
+		}
+
+		step := func(r *opReader, om *OrderedMap, m []*KV) (*OrderedMap, []*KV) {
+			switch r.next(4) {
+			case 0, 1:
+				k, v := r.next(30), r.next(100)
+				i := find(m, k)
+				var ok bool
+				om, ok = om.Set(k, v)
+				assert.Equal(t, i == len(m), ok)
+				m = append(make([]*KV, 0, len(m)+1), m...)
+				if i < len(m) {
+					m[i] = KeyVal(k, v)
+				} else {
+					m = append(m, KeyVal(k, v))
+				}
+			case 2:
+				k := r.next(30)
+				i := find(m, k)
+				var ok bool
+				om, ok = om.Del(k)
+				assert.Equal(t, i < len(m), ok)
+				if i < len(m) {
+					m = append(append([]*KV{}, m[:i]...), m[i+1:]...)
+				}
+			default:
+				if kv, rest, ok := om.FirstRestKV(); ok {
+					assert.Equal(t, m[0].Key, kv.Key)
+					om, m = rest, m[1:]
+				}
+			}
+			return om, m
+		}
+		check := func(t *T, om *OrderedMap, m []*KV) {
+			assertOrderedMap(t, m, om)
+			assert.True(t, om.Equal(NewOrderedMap(m...)))
+		}
+		runModel(t, data, NewOrderedMap(), []*KV{}, step, check)
+	})
+}
+
+func FuzzLazyModel(f *F) {
+	seedModel(f)
+	f.Fuzz(func(t *T, data []byte) {
+		r := &opReader{data}
+		vals := make([]interface{}, r.next(50))
+		for i := range vals {
+			vals[i] = r.next(100)
+		}
+
+		step := func(r *opReader, s Seq, m []interface{}) (Seq, []interface{}) {
+			var m2 []interface{}
+			switch r.next(5) {
+			case 0:
+				s = LMap(func(el interface{}) interface{} { return el.(int) + 1 }, s)
+				for _, el := range m {
+					m2 = append(m2, el.(int)+1)
+				}
+			case 1:
+				n := r.next(10) + 2
+				fn := func(el interface{}) bool { return el.(int)%n != 0 }
+				s = LFilter(fn, s)
+				for _, el := range m {
+					if fn(el) {
+						m2 = append(m2, el)
+					}
+				}
+			case 2:
+				n := r.next(len(m) + 1)
+				s, m2 = LTake(uint64(n), s), m[:n]
+			case 3:
+				n := r.next(100)
+				fn := func(el interface{}) bool { return el.(int) != n }
+				s = LTakeWhile(fn, s)
+				for _, el := range m {
+					if !fn(el) {
+						break
+					}
+					m2 = append(m2, el)
+				}
+			default:
+				n := r.next(len(m) + 1)
+				s, m2 = Drop(uint64(n), s), m[n:]
+			}
+			return s, m2
+		}
+		check := func(t *T, s Seq, m []interface{}) {
+			// Checked twice, since the second time the Lazy's elements have
+			// all been cached
+			assertSeqSlice(t, m, s)
+			assertSeqSlice(t, m, s)
+		}
+		runModel(t, r.b, Seq(ToLazy(NewList(vals...))), vals, step, check)
+	})
+}
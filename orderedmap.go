@@ -0,0 +1,174 @@
+package seq
+
+// OrderedMap is a key/value store which remembers the order its keys were
+// first set in. It pairs a HashMap, mapping each key to the position it was
+// inserted at, with a SortedMap of those positions to the KVs themselves.
+//
+// Setting a key which is already in the OrderedMap changes its value but keeps
+// its position. Deleting a key and then setting it again moves it to the end,
+// as if it had never been set before. Iterating over an OrderedMap yields its
+// KVs in insertion order.
+//
+// A nil *OrderedMap is an empty OrderedMap, and can be both read from and set
+// on. So can a zero OrderedMap.
+type OrderedMap struct {
+	// Both are nil in a zero OrderedMap
+	index *HashMap   // key -> uint64 position
+	order *SortedMap // uint64 position -> *KV
+
+	// The position the next new key will be given
+	next uint64
+}
+
+func compareUint64s(a, b interface{}) int {
+	ai, bi := a.(uint64), b.(uint64)
+	if ai < bi {
+		return -1
+	} else if ai > bi {
+		return 1
+	}
+	return 0
+}
+
+// NewOrderedMap returns a new OrderedMap of the given KVs (or possibly just an
+// empty OrderedMap), in the order they're given
+func NewOrderedMap(kvs ...*KV) *OrderedMap {
+	var om *OrderedMap
+	for i := range kvs {
+		om, _ = om.Set(kvs[i].Key, kvs[i].Val)
+	}
+	return om
+}
+
+// Size returns the number of KVs in the OrderedMap. Completes in O(1) time.
+func (om *OrderedMap) Size() uint64 {
+	if om == nil || om.index == nil {
+		return 0
+	}
+	return om.index.Size()
+}
+
+// Set returns a new OrderedMap with the given value set on the given key. Also
+// returns whether or not this was the first time setting that key (false if it
+// was already there and was overwritten, in which case it keeps its position).
+// Completes in O(log(N)) time.
+func (om *OrderedMap) Set(key, val interface{}) (*OrderedMap, bool) {
+	if om == nil || om.index == nil {
+		om = &OrderedMap{NewHashMap(), NewSortedMap(compareUint64s), 0}
+	}
+
+	if pos, ok := om.index.Get(key); ok {
+		norder, _ := om.order.Set(pos, KeyVal(key, val))
+		return &OrderedMap{om.index, norder, om.next}, false
+	}
+
+	nindex, _ := om.index.Set(key, om.next)
+	norder, _ := om.order.Set(om.next, KeyVal(key, val))
+	return &OrderedMap{nindex, norder, om.next + 1}, true
+}
+
+// Del returns a new OrderedMap with the given key removed from it. Also returns
+// whether or not the key was already there (true if so, false if not). If the
+// key is set again later it will be placed at the end. Completes in O(log(N))
+// time.
+func (om *OrderedMap) Del(key interface{}) (*OrderedMap, bool) {
+	if om == nil || om.index == nil {
+		return om, false
+	}
+	pos, ok := om.index.Get(key)
+	if !ok {
+		return om, false
+	}
+	nindex, _ := om.index.Del(key)
+	norder, _ := om.order.Del(pos)
+	return &OrderedMap{nindex, norder, om.next}, true
+}
+
+// Get returns a value for a given key from the OrderedMap, along with a boolean
+// indicating whether or not the value was found. Completes in O(log(N)) time.
+func (om *OrderedMap) Get(key interface{}) (interface{}, bool) {
+	if om == nil || om.index == nil {
+		return nil, false
+	}
+	pos, ok := om.index.Get(key)
+	if !ok {
+		return nil, false
+	}
+	kv, _ := om.order.Get(pos)
+	return kv.(*KV).Val, true
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. First return
+// value will always be the *KV which was inserted earliest, or nil. Completes
+// in O(log(N)) time.
+func (om *OrderedMap) FirstRest() (interface{}, Seq, bool) {
+	if om.Size() == 0 {
+		return nil, om, false
+	}
+	first, _ := om.order.Min()
+	kv := first.Val.(*KV)
+	nindex, _ := om.index.Del(kv.Key)
+	norder, _ := om.order.Del(first.Key)
+	return kv, &OrderedMap{nindex, norder, om.next}, true
+}
+
+// FirstRestKV is the same as FirstRest, but returns values already casted,
+// which may be convenient in some cases.
+func (om *OrderedMap) FirstRestKV() (*KV, *OrderedMap, bool) {
+	if el, nom, ok := om.FirstRest(); ok {
+		return el.(*KV), nom.(*OrderedMap), true
+	}
+	return nil, nil, false
+}
+
+// Calls fn on each KV in the OrderedMap, in insertion order, until fn returns
+// false. Completes in O(N) time.
+func (om *OrderedMap) each(fn func(interface{}) bool) {
+	if om == nil || om.index == nil {
+		return
+	}
+	for el := range Values(om.order) {
+		if !fn(el.(*KV).Val) {
+			return
+		}
+	}
+}
+
+// Hash implements the Hash method for the Setable interface
+func (om *OrderedMap) Hash(i uint32) uint32 {
+	if om == nil || om.index == nil {
+		return 0
+	}
+	return om.index.Hash(i) // good enough
+}
+
+// Equal implements the Equal method for the Comparable and Setable interfaces.
+// Two OrderedMaps are equal if they have the same keys, in the same order, with
+// equal values.
+func (om *OrderedMap) Equal(v interface{}) bool {
+	om2, ok := v.(*OrderedMap)
+	if !ok || om.Size() != om2.Size() {
+		return false
+	} else if om.Size() == 0 {
+		return true
+	}
+
+	var s, s2 Seq = om.order.sset().ascending(), om2.order.sset().ascending()
+	var el, el2 interface{}
+	for {
+		el, s, ok = s.FirstRest()
+		el2, s2, _ = s2.FirstRest()
+		if !ok {
+			return true
+		}
+		kv, kv2 := el.(*KV).Val.(*KV), el2.(*KV).Val.(*KV)
+		if !equal(kv.Key, kv2.Key) || !equal(kv.Val, kv2.Val) {
+			return false
+		}
+	}
+}
+
+// String is an implementation of String for Stringer interface
+func (om *OrderedMap) String() string {
+	return ToString(om, "{", "}")
+}
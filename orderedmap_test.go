@@ -0,0 +1,175 @@
+package seq
+
+import (
+	"math/rand"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Asserts that the OrderedMap holds the given KVs, in the given order
+func assertOrderedMap(t *T, kvs []*KV, om *OrderedMap) {
+	assert.Equal(t, uint64(len(kvs)), om.Size())
+	assert.Equal(t, uint64(len(kvs)), Size(om))
+	if om != nil && om.index != nil {
+		assert.Equal(t, om.index.Size(), om.order.Size())
+	}
+	got := ToSlice(om)
+	assert.Equal(t, len(kvs), len(got))
+	for i := range kvs {
+		kv := got[i].(*KV)
+		assert.Equal(t, kvs[i].Key, kv.Key)
+		assert.Equal(t, kvs[i].Val, kv.Val)
+		val, ok := om.Get(kvs[i].Key)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, kvs[i].Val, val)
+	}
+}
+
+// Test creating an OrderedMap and calling the Seq interface methods on it
+func TestOrderedMapSeq(t *T) {
+	kvs, ints := kvints(
+		KeyVal(3, "three"),
+		KeyVal(1, "one"),
+		KeyVal("two", 2),
+	)
+
+	m := NewOrderedMap(kvs...)
+	ms := testSeqGen(t, m, ints)
+	assert.Equal(t, uint64(0), Size(ms))
+	assert.Equal(t, "{ 3 -> three 1 -> one two -> 2 }", m.String())
+
+	var nilpointer *OrderedMap
+	assert.Equal(t, nilpointer, NewOrderedMap())
+	assert.Equal(t, uint64(0), Size(NewOrderedMap()))
+	_, _, ok := NewOrderedMap().FirstRest()
+	assert.Equal(t, false, ok)
+
+	var keys []interface{}
+	for k, v := range m.Pairs() {
+		keys = append(keys, k)
+		got, _ := m.Get(k)
+		assert.Equal(t, got, v)
+	}
+	assert.Equal(t, []interface{}{3, 1, "two"}, keys)
+}
+
+// Test that overwriting a key keeps its position, and that deleting and then
+// setting it again moves it to the end
+func TestOrderedMapSetDel(t *T) {
+	m := NewOrderedMap(KeyVal(1, "a"), KeyVal(2, "b"), KeyVal(3, "c"))
+
+	m2, ok := m.Set(2, "B")
+	assert.Equal(t, false, ok)
+	assertOrderedMap(t, []*KV{KeyVal(1, "a"), KeyVal(2, "B"), KeyVal(3, "c")}, m2)
+
+	m3, ok := m2.Del(2)
+	assert.Equal(t, true, ok)
+	assertOrderedMap(t, []*KV{KeyVal(1, "a"), KeyVal(3, "c")}, m3)
+	_, ok = m3.Get(2)
+	assert.Equal(t, false, ok)
+
+	m4, ok := m3.Set(2, "again")
+	assert.Equal(t, true, ok)
+	assertOrderedMap(t, []*KV{KeyVal(1, "a"), KeyVal(3, "c"), KeyVal(2, "again")}, m4)
+
+	m5, ok := m4.Del(5)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, true, m5 == m4)
+
+	// Old versions are unaffected
+	assertOrderedMap(t, []*KV{KeyVal(1, "a"), KeyVal(2, "b"), KeyVal(3, "c")}, m)
+
+	var empty *OrderedMap
+	empty, ok = empty.Del(1)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, uint64(0), empty.Size())
+	empty, ok = empty.Set(1, "a")
+	assert.Equal(t, true, ok)
+	assertOrderedMap(t, []*KV{KeyVal(1, "a")}, empty)
+}
+
+// Test setting and deleting many random keys on an OrderedMap against a slice
+// of KVs kept in the same order
+func TestOrderedMapModel(t *T) {
+	r := rand.New(rand.NewSource(1))
+	var m *OrderedMap
+	var kvs []*KV
+	maps, kvss := []*OrderedMap{m}, [][]*KV{kvs}
+	for i := 0; i < 2000; i++ {
+		key := r.Intn(100)
+		j := 0
+		for ; j < len(kvs) && kvs[j].Key != key; j++ {
+		}
+
+		nkvs := make([]*KV, 0, len(kvs)+1)
+		if r.Intn(3) == 0 {
+			var ok bool
+			m, ok = m.Del(key)
+			assert.Equal(t, j < len(kvs), ok)
+			nkvs = append(nkvs, kvs[:j]...)
+			if j < len(kvs) {
+				nkvs = append(nkvs, kvs[j+1:]...)
+			}
+		} else {
+			var ok bool
+			m, ok = m.Set(key, i)
+			assert.Equal(t, j == len(kvs), ok)
+			nkvs = append(nkvs, kvs...)
+			if j < len(kvs) {
+				nkvs[j] = KeyVal(key, i)
+			} else {
+				nkvs = append(nkvs, KeyVal(key, i))
+			}
+		}
+		kvs = nkvs
+		maps, kvss = append(maps, m), append(kvss, kvs)
+	}
+
+	for i := range maps {
+		assertOrderedMap(t, kvss[i], maps[i])
+	}
+}
+
+// Test that OrderedMaps are equal only if their keys are in the same order
+func TestOrderedMapEqual(t *T) {
+	m1 := NewOrderedMap(KeyVal(1, "a"), KeyVal(2, "b"))
+	m2, _ := NewOrderedMap(KeyVal(1, "x"), KeyVal(3, "c")).Del(3)
+	m2, _ = m2.Set(1, "a")
+	m2, _ = m2.Set(2, "b")
+	m3 := NewOrderedMap(KeyVal(2, "b"), KeyVal(1, "a"))
+
+	assert.Equal(t, true, m1.Equal(m2))
+	assert.Equal(t, m1.Hash(0), m2.Hash(0))
+	assert.Equal(t, false, m1.Equal(m3))
+	assert.Equal(t, m1.Hash(0), m3.Hash(0))
+	m4, _ := m1.Set(2, "c")
+	assert.Equal(t, false, m1.Equal(m4))
+	assert.Equal(t, false, m1.Equal(NewHashMap(KeyVal(1, "a"), KeyVal(2, "b"))))
+	assert.Equal(t, true, NewOrderedMap().Equal(NewOrderedMap()))
+	empty, _ := NewOrderedMap(KeyVal(1, "a")).Del(1)
+	assert.Equal(t, true, empty.Equal(NewOrderedMap()))
+}
+
+// Test that a zero OrderedMap acts like an empty one
+func TestOrderedMapZero(t *T) {
+	var om OrderedMap
+	assertOrderedMap(t, nil, &om)
+	_, ok := om.Get(1)
+	assert.Equal(t, false, ok)
+	nom, ok := om.Del(1)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, &om, nom)
+	_, _, ok = om.FirstRest()
+	assert.Equal(t, false, ok)
+	assert.Equal(t, uint32(0), om.Hash(0))
+	assert.Equal(t, true, om.Equal(NewOrderedMap()))
+	assert.Equal(t, true, NewOrderedMap().Equal(&om))
+	assert.Equal(t, "{}", marshalJSONString(t, &om))
+
+	nom, ok = om.Set(1, "a")
+	assert.Equal(t, true, ok)
+	nom, _ = nom.Set(2, "b")
+	assertOrderedMap(t, []*KV{KeyVal(1, "a"), KeyVal(2, "b")}, nom)
+	assertOrderedMap(t, nil, &om)
+}
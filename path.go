@@ -0,0 +1,261 @@
+package seq
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// The In functions follow a path of keys down through nested HashMaps, Lists
+// and Vectors. A HashMap is keyed by its own keys, and a List or Vector by the
+// index of an element, which can be any non-negative integer. Changing a value
+// copies only the nodes along the path to it; everything else is shared with
+// the original.
+
+// ErrPath is the error returned by SetIn, UpdateIn and DelIn when a path can't
+// be followed. This happens when a value along the path isn't a HashMap, List
+// or Vector, when a key used on a List or Vector isn't a non-negative integer
+// or is past its end, or when the path is empty.
+type ErrPath struct {
+	// The path up to and including the key which couldn't be used
+	Path []interface{}
+
+	// The value that key couldn't be used on
+	Val interface{}
+}
+
+func (err ErrPath) Error() string {
+	if len(err.Path) == 0 {
+		return "empty path"
+	}
+	key := err.Path[len(err.Path)-1]
+	return fmt.Sprintf("can't use key %v of path %v on %T", key, err.Path, err.Val)
+}
+
+// Returns the key as an index into a List or Vector, or false if it isn't a
+// non-negative integer
+func pathIndex(key interface{}) (uint64, bool) {
+	rv := reflect.ValueOf(key)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i := rv.Int(); i >= 0 {
+			return uint64(i), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), true
+	}
+	return 0, false
+}
+
+// Returns the value on the given key in cur, and whether it was there. usable
+// is false if cur isn't a HashMap, List or Vector, if it's a HashMap and key
+// can't be hashed, or if it's a List or Vector and key isn't an index.
+func pathGet(cur, key interface{}) (val interface{}, ok, usable bool) {
+	if hm, isHM := cur.(*HashMap); isHM {
+		val, ok, err := hm.TryGet(key)
+		return val, ok, err == nil
+	}
+
+	i, isIndex := pathIndex(key)
+	if !isIndex {
+		return nil, false, false
+	}
+	switch c := cur.(type) {
+	case *List:
+		val, ok = c.Nth(i)
+		return val, ok, true
+	case *Vector:
+		val, ok = c.Nth(i)
+		return val, ok, true
+	}
+	return nil, false, false
+}
+
+// Returns a copy of cur with val set on the given key, or if keep is false
+// with the key removed. cur must be usable with key, see pathGet. Returns
+// false if cur is a List or Vector and key is beyond the end of it.
+func pathSet(cur, key, val interface{}, keep bool) (interface{}, bool) {
+	if hm, isHM := cur.(*HashMap); isHM {
+		if keep {
+			nhm, _ := hm.Set(key, val)
+			return nhm, true
+		}
+		nhm, _ := hm.Del(key)
+		return nhm, true
+	}
+
+	i, _ := pathIndex(key)
+	switch c := cur.(type) {
+	case *List:
+		if keep {
+			return listAssoc(c, i, val)
+		}
+		return listDel(c, i), true
+	case *Vector:
+		if keep {
+			return c.Assoc(i, val)
+		}
+		return vectorDel(c, i), true
+	}
+	panic(fmt.Sprintf("can't set key on %T", cur))
+}
+
+// Returns the cells of the List before index i, and the cell at i, or false if
+// the List isn't that long
+func listSplit(l *List, i uint64) ([]interface{}, *List, bool) {
+	prefix := make([]interface{}, 0, i)
+	for ; i > 0; i-- {
+		if l == nil {
+			return nil, nil, false
+		}
+		prefix = append(prefix, l.el)
+		l = l.next
+	}
+	return prefix, l, true
+}
+
+// Returns the given elements followed by the List, sharing the List's cells
+func listJoin(prefix []interface{}, l *List) *List {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		l = &List{prefix[i], l}
+	}
+	return l
+}
+
+// Returns a copy of the List with the element at index i replaced by val, or
+// val appended if i is the List's size, and true. Only the cells before i are
+// copied. Returns false, and the List unchanged, if i is beyond its end.
+func listAssoc(l *List, i uint64, val interface{}) (*List, bool) {
+	prefix, cell, ok := listSplit(l, i)
+	if !ok {
+		return l, false
+	} else if cell == nil {
+		return listJoin(prefix, &List{val, nil}), true
+	}
+	return listJoin(prefix, &List{val, cell.next}), true
+}
+
+// Returns a copy of the List with the element at index i removed. Only the
+// cells before i are copied. If i is beyond the end the List is returned
+// unchanged.
+func listDel(l *List, i uint64) *List {
+	prefix, cell, ok := listSplit(l, i)
+	if !ok || cell == nil {
+		return l
+	}
+	return listJoin(prefix, cell.next)
+}
+
+// Returns a copy of the Vector with the element at index i removed. Everything
+// before i is shared, but everything after it has to be appended again. If i is
+// beyond the end the Vector is returned unchanged.
+func vectorDel(v *Vector, i uint64) *Vector {
+	size := v.Size()
+	if i >= size {
+		return v
+	}
+	nv, _ := v.Slice(0, i)
+	for j := i + 1; j < size; j++ {
+		el, _ := v.Nth(j)
+		nv = nv.Append(el)
+	}
+	return nv
+}
+
+// Returns a copy of cur with the value at the end of path, starting from the
+// key at index depth, changed by fn. cur must be a HashMap, List or Vector, or
+// nil, in which case a new HashMap is created for it. If fn changes nothing
+// cur itself is returned.
+func alterIn(cur interface{}, path []interface{}, depth int, fn setAlterFn) (interface{}, error) {
+	orig := cur
+	if cur == nil {
+		cur = NewHashMap()
+	}
+
+	key := path[depth]
+	child, ok, usable := pathGet(cur, key)
+	if !usable {
+		return nil, ErrPath{path[:depth+1], cur}
+	}
+
+	var nchild interface{}
+	keep := true
+	if depth == len(path)-1 {
+		if nchild, keep = fn(child, ok); !ok && !keep {
+			return orig, nil
+		}
+	} else {
+		var err error
+		if nchild, err = alterIn(child, path, depth+1, fn); err != nil {
+			return nil, err
+		} else if nchild == nil || (ok && nchild == child) {
+			// Nothing below was changed, or created
+			return orig, nil
+		}
+	}
+
+	ncur, ok := pathSet(cur, key, nchild, keep)
+	if !ok {
+		return nil, ErrPath{path[:depth+1], cur}
+	}
+	return ncur, nil
+}
+
+// Changes the value at the end of the path in s with fn, see alterIn
+func alterInSeq(s Seq, path []interface{}, fn setAlterFn) (Seq, error) {
+	if len(path) == 0 {
+		return s, ErrPath{path, s}
+	}
+
+	ns, err := alterIn(s, path, 0, fn)
+	if err != nil || ns == nil {
+		return s, err
+	}
+	return ns.(Seq), nil
+}
+
+// GetIn returns the value found by following the given path of keys down
+// through the Seq, which along with the values along the path may be any mix of
+// HashMaps, Lists and Vectors. Also returns whether the value was found, which
+// it isn't if any key along the path is missing or can't be used. An empty path
+// returns the Seq itself.
+func GetIn(s Seq, path []interface{}) (interface{}, bool) {
+	var cur interface{} = s
+	for _, key := range path {
+		val, ok, _ := pathGet(cur, key)
+		if !ok {
+			return nil, false
+		}
+		cur = val
+	}
+	return cur, true
+}
+
+// SetIn returns a copy of the Seq with the value at the end of the given path
+// of keys set to val, see GetIn. Only the nodes along the path are copied. Any
+// HashMap missing along the path is created, as is the Seq itself if it's nil.
+// An index into a List or Vector may be one past its end, to append to it.
+// Returns an ErrPath, and the Seq unchanged, if the path can't be followed.
+func SetIn(s Seq, path []interface{}, val interface{}) (Seq, error) {
+	return alterInSeq(s, path, func(interface{}, bool) (interface{}, bool) {
+		return val, true
+	})
+}
+
+// UpdateIn is like SetIn, but sets the value at the end of the path to fn
+// called on the value which is there now, or on nil if there isn't one.
+func UpdateIn(s Seq, path []interface{}, fn func(interface{}) interface{}) (Seq, error) {
+	return alterInSeq(s, path, func(cur interface{}, _ bool) (interface{}, bool) {
+		return fn(cur), true
+	})
+}
+
+// DelIn returns a copy of the Seq with the value at the end of the given path
+// of keys removed from whichever HashMap, List or Vector holds it, see GetIn.
+// If there's no value there the Seq is returned unchanged, and nothing is
+// created. Returns an ErrPath, and the Seq unchanged, if the path can't be
+// followed.
+func DelIn(s Seq, path []interface{}) (Seq, error) {
+	return alterInSeq(s, path, func(interface{}, bool) (interface{}, bool) {
+		return nil, false
+	})
+}
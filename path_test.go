@@ -0,0 +1,235 @@
+package seq
+
+import (
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pathOf(keys ...interface{}) []interface{} {
+	return keys
+}
+
+// Returns the Seq at the end of the path
+func getSeqIn(s Seq, keys ...interface{}) Seq {
+	val, _ := GetIn(s, keys)
+	return val.(Seq)
+}
+
+// Returns a nested document of HashMaps, Lists and Vectors to test paths on
+func pathDoc() *HashMap {
+	return NewHashMap(
+		KeyVal("name", "svc"),
+		KeyVal("ports", NewList(80, 443)),
+		KeyVal("hosts", NewVector(
+			NewHashMap(KeyVal("addr", "a"), KeyVal("tags", NewList("x"))),
+			NewHashMap(KeyVal("addr", "b")),
+		)),
+		KeyVal("limits", NewHashMap(KeyVal("cpu", 2), KeyVal("mem", 512))),
+	)
+}
+
+// Test getting values at the ends of paths
+func TestGetIn(t *T) {
+	doc := pathDoc()
+	assertGet := func(expected interface{}, keys ...interface{}) {
+		val, ok := GetIn(doc, keys)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, expected, val)
+	}
+	assertMissing := func(keys ...interface{}) {
+		val, ok := GetIn(doc, keys)
+		assert.Equal(t, false, ok)
+		assert.Nil(t, val)
+	}
+
+	assertGet("svc", "name")
+	assertGet(443, "ports", 1)
+	assertGet(443, "ports", uint8(1))
+	assertGet("b", "hosts", 1, "addr")
+	assertGet("x", "hosts", 0, "tags", 0)
+	assertGet(512, "limits", "mem")
+
+	root, ok := GetIn(doc, nil)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, true, root == Seq(doc))
+
+	assertMissing("nope")
+	assertMissing("ports", 2)
+	assertMissing("ports", -1)
+	assertMissing("ports", "0")
+	assertMissing("name", "x")
+	assertMissing("hosts", 1, "tags", 0)
+	assertMissing("limits", []int{1})
+
+	_, ok = GetIn(nil, pathOf("a"))
+	assert.Equal(t, false, ok)
+}
+
+// Test setting values at the ends of paths, including ones which don't exist
+// yet, and that the original is never changed
+func TestSetIn(t *T) {
+	doc := pathDoc()
+	orig := pathDoc()
+	assertSet := func(val interface{}, keys ...interface{}) Seq {
+		s, err := SetIn(doc, keys, val)
+		assert.Nil(t, err)
+		got, ok := GetIn(s, keys)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, val, got)
+		assert.Equal(t, true, doc.Equal(orig))
+		return s
+	}
+
+	s := assertSet("web", "name")
+	assert.Equal(t, doc.Size(), Size(s))
+	s = assertSet(8080, "ports", 0)
+	assert.Equal(t, []interface{}{8080, 443}, ToSlice(getSeqIn(s, "ports")))
+	s = assertSet(8443, "ports", 2)
+	assert.Equal(t, []interface{}{80, 443, 8443}, ToSlice(getSeqIn(s, "ports")))
+	s = assertSet("c", "hosts", 2)
+	assert.Equal(t, uint64(3), Size(getSeqIn(s, "hosts")))
+	assertSet("y", "hosts", 0, "tags", 0)
+	assertSet("y", "hosts", 0, "tags", 1)
+
+	// Missing levels are created as HashMaps
+	s = assertSet(true, "a", "b", "c")
+	expected, _ := doc.Set("a", NewHashMap(KeyVal("b", NewHashMap(KeyVal("c", true)))))
+	assert.Equal(t, true, s.(*HashMap).Equal(expected))
+	assertSet(1, "hosts", 1, "tags", "first")
+
+	s, err := SetIn(nil, pathOf("a", 0), "x")
+	assert.Nil(t, err)
+	assert.Equal(t, true, s.(*HashMap).Equal(
+		NewHashMap(KeyVal("a", NewHashMap(KeyVal(0, "x")))),
+	))
+}
+
+// Test that setting a value only copies the nodes along its path, sharing
+// everything else with the original
+func TestSetInSharing(t *T) {
+	doc := pathDoc()
+	s, err := SetIn(doc, pathOf("hosts", 0, "addr"), "z")
+	assert.Nil(t, err)
+
+	for _, key := range []string{"name", "ports", "limits"} {
+		v1, _ := doc.Get(key)
+		v2, _ := s.(*HashMap).Get(key)
+		assert.Equal(t, true, v1 == v2)
+	}
+	h1, _ := GetIn(doc, pathOf("hosts", 1))
+	h2, _ := GetIn(s, pathOf("hosts", 1))
+	assert.Equal(t, true, h1 == h2)
+	t1, _ := GetIn(doc, pathOf("hosts", 0, "tags"))
+	t2, _ := GetIn(s, pathOf("hosts", 0, "tags"))
+	assert.Equal(t, true, t1 == t2)
+
+	// The tail of a List after the changed index is shared too
+	l := NewList(1, 2, 3, 4)
+	nl, err := SetIn(l, pathOf(1), 5)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 5, 3, 4}, ToSlice(nl))
+	assert.Equal(t, true, l.next.next == nl.(*List).next.next)
+	assert.Equal(t, []interface{}{1, 2, 3, 4}, ToSlice(l))
+}
+
+// Test updating values at the ends of paths with a function
+func TestUpdateIn(t *T) {
+	doc := pathDoc()
+	inc := func(v interface{}) interface{} {
+		if v == nil {
+			return 1
+		}
+		return v.(int) + 1
+	}
+
+	s, err := UpdateIn(doc, pathOf("limits", "cpu"), inc)
+	assert.Nil(t, err)
+	cpu, _ := GetIn(s, pathOf("limits", "cpu"))
+	assert.Equal(t, 3, cpu)
+	cpu, _ = GetIn(doc, pathOf("limits", "cpu"))
+	assert.Equal(t, 2, cpu)
+
+	s, err = UpdateIn(s, pathOf("limits", "disk"), inc)
+	assert.Nil(t, err)
+	disk, _ := GetIn(s, pathOf("limits", "disk"))
+	assert.Equal(t, 1, disk)
+
+	s, err = UpdateIn(s, pathOf("ports", 1), inc)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{80, 444}, ToSlice(getSeqIn(s, "ports")))
+}
+
+// Test deleting values at the ends of paths
+func TestDelIn(t *T) {
+	doc := pathDoc()
+	orig := pathDoc()
+
+	s, err := DelIn(doc, pathOf("limits", "cpu"))
+	assert.Nil(t, err)
+	limits, _ := GetIn(s, pathOf("limits"))
+	assert.Equal(t, true, limits.(*HashMap).Equal(NewHashMap(KeyVal("mem", 512))))
+
+	s, err = DelIn(doc, pathOf("ports", 0))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{443}, ToSlice(getSeqIn(s, "ports")))
+
+	s, err = DelIn(doc, pathOf("hosts", 0))
+	assert.Nil(t, err)
+	addr, _ := GetIn(s, pathOf("hosts", 0, "addr"))
+	assert.Equal(t, "b", addr)
+	assert.Equal(t, uint64(1), Size(getSeqIn(s, "hosts")))
+	assert.Equal(t, true, doc.Equal(orig))
+
+	// Deleting something which isn't there changes and creates nothing
+	for _, keys := range [][]interface{}{
+		pathOf("nope"),
+		pathOf("nope", "a", "b"),
+		pathOf("ports", 5),
+		pathOf("hosts", 1, "tags", 0),
+	} {
+		s, err := DelIn(doc, keys)
+		assert.Nil(t, err)
+		assert.Equal(t, true, s == Seq(doc))
+	}
+	s, err = DelIn(nil, pathOf("a"))
+	assert.Nil(t, err)
+	assert.Nil(t, s)
+}
+
+// Test the errors returned for paths which can't be followed
+func TestInErrors(t *T) {
+	doc := pathDoc()
+	assertErr := func(err error, val interface{}, keys ...interface{}) {
+		assert.Equal(t, ErrPath{keys, val}, err)
+	}
+
+	s, err := SetIn(doc, pathOf("name", "x"), 1)
+	assertErr(err, "svc", "name", "x")
+	assert.Equal(t, true, s == Seq(doc))
+
+	ports, _ := doc.Get("ports")
+	_, err = SetIn(doc, pathOf("ports", 3), 1)
+	assertErr(err, ports, "ports", 3)
+	_, err = SetIn(doc, pathOf("ports", "a"), 1)
+	assertErr(err, ports, "ports", "a")
+	_, err = DelIn(doc, pathOf("ports", -1))
+	assertErr(err, ports, "ports", -1)
+
+	hosts, _ := doc.Get("hosts")
+	_, err = UpdateIn(doc, pathOf("hosts", 5, "addr"), func(interface{}) interface{} {
+		return "c"
+	})
+	assertErr(err, hosts, "hosts", 5)
+
+	set := NewSet(1)
+	_, err = SetIn(set, pathOf(1), 2)
+	assertErr(err, set, 1)
+
+	_, err = SetIn(doc, nil, 1)
+	assert.Equal(t, ErrPath{nil, doc}, err)
+	assert.Equal(t, "empty path", err.Error())
+
+	_, err = SetIn(doc, pathOf("name", "x"), 1)
+	assert.Equal(t, "can't use key x of path [name x] on string", err.Error())
+}
@@ -0,0 +1,157 @@
+package seq
+
+// Queue is an implementation of Seq in the form of a persistent FIFO queue.
+// Elements are pushed onto the back and popped off the front. It's made up of
+// two Lists: the front, which elements are popped from, and the rear, which
+// holds pushed elements in reverse order. When the front runs out the rear is
+// reversed to become the new front, so Push, Pop and Peek all complete in
+// amortized O(1) time. Like List, a Queue shares its nodes with the Queue it
+// was made from.
+//
+// The amortized bound holds as long as each version of a Queue is popped from
+// at most once. Popping the same version repeatedly at the point where its
+// rear needs to be reversed will reverse it each time.
+//
+// A nil *Queue is an empty Queue, and all operations which leave a Queue empty
+// return nil. A zero Queue, which is what UnmarshalJSON makes from an empty JSON
+// array, is empty too.
+type Queue struct {
+
+	// Only empty if the whole Queue is
+	front *List
+
+	// Pushed elements which haven't yet been moved to the front, newest first
+	rear *List
+
+	size uint64
+}
+
+// NewQueue returns a new Queue comprised of the given elements (or no elements,
+// for an empty queue), with the first element at the front
+func NewQueue(els ...interface{}) *Queue {
+	return ToQueue(NewList(els...))
+}
+
+// Returns a Queue with the given front and rear, moving the rear to the front
+// if the front is empty
+func newQueue(front, rear *List, size uint64) *Queue {
+	if front == nil {
+		if rear == nil {
+			return nil
+		}
+		front, rear = Reverse(rear).(*List), nil
+	}
+	return &Queue{front, rear, size}
+}
+
+// Size returns the number of elements in the Queue. Completes in O(1) time.
+func (q *Queue) Size() uint64 {
+	if q == nil {
+		return 0
+	}
+	return q.size
+}
+
+// Push returns a copy of the Queue with the given element added to the back of
+// it. Completes in O(1) time.
+func (q *Queue) Push(el interface{}) *Queue {
+	if q.Size() == 0 {
+		return &Queue{front: &List{el, nil}, size: 1}
+	}
+	return &Queue{q.front, q.rear.Prepend(el), q.size + 1}
+}
+
+// Peek returns the element at the front of the Queue, and true. If the Queue is
+// empty returns nil and false. Completes in O(1) time.
+func (q *Queue) Peek() (interface{}, bool) {
+	if q.Size() == 0 {
+		return nil, false
+	}
+	return q.front.el, true
+}
+
+// Pop returns the element at the front of the Queue, a copy of the Queue with
+// that element removed, and true. If the Queue is empty returns nil, the empty
+// Queue, and false. Completes in amortized O(1) time.
+func (q *Queue) Pop() (interface{}, *Queue, bool) {
+	if q.Size() == 0 {
+		return nil, q, false
+	}
+	return q.front.el, newQueue(q.front.next, q.rear, q.size-1), true
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. It is the same
+// as Pop.
+func (q *Queue) FirstRest() (interface{}, Seq, bool) {
+	el, nq, ok := q.Pop()
+	return el, nq, ok
+}
+
+// Calls fn on each element of the Queue, front to back, until fn returns false.
+// The rear is reversed once, rather than being moved to the front one Pop at a
+// time.
+func (q *Queue) each(fn func(interface{}) bool) {
+	if q == nil {
+		return
+	}
+	for l := q.front; l != nil; l = l.next {
+		if !fn(l.el) {
+			return
+		}
+	}
+	for l := Reverse(q.rear).(*List); l != nil; l = l.next {
+		if !fn(l.el) {
+			return
+		}
+	}
+}
+
+// Returns the elements of the Queue as a List, front first. If the rear is
+// empty this is the front itself, otherwise the front is copied.
+func (q *Queue) list() *List {
+	if q == nil {
+		return nil
+	} else if q.rear == nil {
+		return q.front
+	}
+	return Reverse(q.rear).(*List).PrependSeq(q.front)
+}
+
+// Hash implements the Hash method for the Setable interface
+func (q *Queue) Hash(i uint32) uint32 {
+	sum := uint32(0)
+	q.each(func(el interface{}) bool {
+		sum += hash(el, i)
+		return true
+	})
+	return sum
+}
+
+// Equal implements Equal for the Setable and Comparable interfaces. Two Queues
+// are equal if they hold equal elements in the same order, regardless of how
+// those elements are split between their fronts and rears.
+func (q *Queue) Equal(v interface{}) bool {
+	q2, ok := v.(*Queue)
+	if !ok || q.Size() != q2.Size() {
+		return false
+	}
+	return q.list().Equal(q2.list())
+}
+
+// String is an implementation of String for Stringer interface.
+func (q *Queue) String() string {
+	return ToString(q, "<-(", ")-<")
+}
+
+// ToQueue returns the elements in the Seq as a Queue, with the Seq's first
+// element at the front. If the given Seq is already a Queue it will complete in
+// O(1) time. If it's a List the Queue will share its nodes, which completes in
+// O(N) time since the List's size must be counted. Otherwise it has similar
+// properties as ToList.
+func ToQueue(s Seq) *Queue {
+	if q, ok := s.(*Queue); ok {
+		return q
+	}
+	l := ToList(s)
+	return newQueue(l, nil, Size(l))
+}
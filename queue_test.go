@@ -0,0 +1,98 @@
+package seq
+
+import (
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test creating a Queue and calling the Seq interface methods on it
+func TestQueueSeq(t *T) {
+	ints := []interface{}{1, "a", 5.0}
+
+	// Build up the Queue with Push as well, so some of the elements start out
+	// in the rear
+	q := NewQueue(ints[0]).Push(ints[1]).Push(ints[2])
+	assert.Equal(t, true, q.Equal(NewQueue(ints...)))
+	sq := testSeqGen(t, q, ints)
+
+	// sq should be empty at this point
+	q = ToQueue(sq)
+	var nilpointer *Queue
+	assert.Equal(t, uint64(0), Size(q))
+	assert.Equal(t, nilpointer, q)
+	assert.Equal(t, nilpointer, NewQueue())
+}
+
+// Test pushing and popping elements, and that older versions of the Queue are
+// never changed by either
+func TestQueuePushPop(t *T) {
+	var q *Queue
+	var ints []interface{}
+	queues, intss := []*Queue{q}, [][]interface{}{ints}
+	for i := 0; i < 100; i++ {
+		q, ints = q.Push(i), append(ints[:len(ints):len(ints)], i)
+
+		// Popping every third element means elements are popped both from
+		// the original front and from fronts which were reversed from the rear
+		if i%3 == 2 {
+			el, nq, ok := q.Pop()
+			assert.Equal(t, true, ok)
+			assert.Equal(t, ints[0], el)
+			q, ints = nq, ints[1:]
+		}
+		queues, intss = append(queues, q), append(intss, ints)
+	}
+
+	for i := range queues {
+		assert.Equal(t, uint64(len(intss[i])), queues[i].Size())
+		assert.Equal(t, len(intss[i]), len(ToSlice(queues[i])))
+		for j, el := range ToSlice(queues[i]) {
+			assert.Equal(t, intss[i][j], el)
+		}
+	}
+
+	for i := range ints {
+		el, ok := q.Peek()
+		assert.Equal(t, true, ok)
+		assert.Equal(t, ints[i], el)
+		_, q, _ = q.Pop()
+	}
+	_, ok := q.Peek()
+	assert.Equal(t, false, ok)
+	_, q, ok = q.Pop()
+	assert.Equal(t, false, ok)
+	assert.Nil(t, q)
+}
+
+// Test that Queues are equal based only on their elements and not how those
+// are split between the front and rear
+func TestQueueEqual(t *T) {
+	q1 := NewQueue(1, 2, 3, 4)
+	q2 := NewQueue(1, 2).Push(3).Push(4)
+	_, q3, _ := NewQueue(0, 1).Push(2).Push(3).Push(4).Pop()
+
+	assert.Equal(t, true, q1.Equal(q2))
+	assert.Equal(t, true, q2.Equal(q3))
+	assert.Equal(t, q1.Hash(0), q3.Hash(0))
+	assert.Equal(t, false, q1.Equal(q2.Push(5)))
+	assert.Equal(t, false, q1.Equal(NewQueue(1, 2, 4, 3)))
+	assert.Equal(t, false, q1.Equal(NewList(1, 2, 3, 4)))
+	assert.Equal(t, true, (*Queue)(nil).Equal(NewQueue()))
+
+	s := NewSet(q1)
+	_, ok := s.GetVal(q3)
+	assert.Equal(t, true, ok)
+}
+
+// Test that ToQueue shares the nodes of a List it's given
+func TestToQueue(t *T) {
+	l := NewList(1, 2, 3)
+	q := ToQueue(l)
+	assert.True(t, l == q.front)
+	assert.Equal(t, uint64(3), q.Size())
+	assert.True(t, q == ToQueue(q))
+
+	_, q, _ = q.Push(4).Pop()
+	assert.Equal(t, "<-( 2 3 4 )-<", q.String())
+}
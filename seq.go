@@ -31,14 +31,29 @@ type Comparable interface {
 }
 
 // Size returns the number of elements contained in the data structure. In
-// general this completes in O(N) time, except for Set and HashMap for which it
-// completes in O(1)
+// general this completes in O(N) time, except for Set, HashMap, Vector, Queue,
+// Deque, Heap, SortedSet, SortedMap and OrderedMap for which it completes in
+// O(1)
 func Size(s Seq) uint64 {
 	switch st := s.(type) {
 	case *Set:
 		return st.Size()
 	case *HashMap:
 		return st.Size()
+	case *Vector:
+		return st.Size()
+	case *Queue:
+		return st.Size()
+	case *Deque:
+		return st.Size()
+	case *Heap:
+		return st.Size()
+	case *SortedSet:
+		return st.Size()
+	case *SortedMap:
+		return st.Size()
+	case *OrderedMap:
+		return st.Size()
 	default:
 	}
 
@@ -56,13 +71,25 @@ func Size(s Seq) uint64 {
 // any implicit order to it that order will be kept. An empty Seq will return an
 // empty slice; nil is never returned. In general this completes in O(N) time.
 func ToSlice(s Seq) []interface{} {
+	ret := make([]interface{}, 0, 8)
+	for el := range Values(s) {
+		ret = append(ret, el)
+	}
+	return ret
+}
+
+// ToSliceErr is like ToSlice, but also returns the error which ended the Seq,
+// if it is an ErrSeq which ended with one. The elements read before the error
+// are still returned.
+func ToSliceErr(s Seq) ([]interface{}, error) {
 	var el interface{}
+	var rest Seq
 	var ok bool
-	for ret := make([]interface{}, 0, 8); ; {
-		if el, s, ok = s.FirstRest(); ok {
+	for ret := make([]interface{}, 0, 8); ; s = rest {
+		if el, rest, ok = s.FirstRest(); ok {
 			ret = append(ret, el)
 		} else {
-			return ret
+			return ret, Err(rest)
 		}
 	}
 }
@@ -72,22 +99,13 @@ func ToSlice(s Seq) []interface{} {
 func ToString(s Seq, dstart, dend string) string {
 	buf := bytes.NewBufferString(dstart)
 	buf.WriteString(" ")
-	var el interface{}
-	var strel fmt.Stringer
-	var rest Seq
-	var ok bool
-	for {
-		if el, rest, ok = s.FirstRest(); ok {
-			if strel, ok = el.(fmt.Stringer); ok {
-				buf.WriteString(strel.String())
-			} else {
-				buf.WriteString(fmt.Sprintf("%v", el))
-			}
-			buf.WriteString(" ")
-			s = rest
+	for el := range Values(s) {
+		if strel, ok := el.(fmt.Stringer); ok {
+			buf.WriteString(strel.String())
 		} else {
-			break
+			buf.WriteString(fmt.Sprintf("%v", el))
 		}
+		buf.WriteString(" ")
 	}
 	buf.WriteString(dend)
 	return buf.String()
@@ -96,29 +114,18 @@ func ToString(s Seq, dstart, dend string) string {
 // Reverse returns a reversed copy of the List. Completes in O(N) time.
 func Reverse(s Seq) Seq {
 	l := NewList()
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); ok {
-			l = l.Prepend(el)
-		} else {
-			return l
-		}
+	for el := range Values(s) {
+		l = l.Prepend(el)
 	}
+	return l
 }
 
 // Map returns a Seq consisting of the result of applying fn to each element in the
 // given Seq. Completes in O(N) time.
 func Map(fn func(interface{}) interface{}, s Seq) Seq {
 	l := NewList()
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); ok {
-			l = l.Prepend(fn(el))
-		} else {
-			break
-		}
+	for el := range Values(s) {
+		l = l.Prepend(fn(el))
 	}
 	return Reverse(l)
 }
@@ -137,69 +144,64 @@ type ReduceFn func(acc, el interface{}) (interface{}, bool)
 // works. The return value is the result of the reduction. Completes in O(N)
 // time.
 func Reduce(fn ReduceFn, acc interface{}, s Seq) interface{} {
-	var el interface{}
-	var ok, stop bool
-	for {
-		if el, s, ok = s.FirstRest(); ok {
-			acc, stop = fn(acc, el)
-			if stop {
-				break
-			}
-		} else {
+	var stop bool
+	for el := range Values(s) {
+		if acc, stop = fn(acc, el); stop {
 			break
 		}
 	}
 	return acc
 }
 
+// ReduceErr is like Reduce, but also returns the error which ended the Seq, if
+// it is an ErrSeq which ended with one before the reduction was stopped. The
+// accumulator as it was at that point is returned alongside the error.
+func ReduceErr(fn ReduceFn, acc interface{}, s Seq) (interface{}, error) {
+	var el interface{}
+	var rest Seq
+	var ok, stop bool
+	for ; ; s = rest {
+		if el, rest, ok = s.FirstRest(); !ok {
+			return acc, Err(rest)
+		} else if acc, stop = fn(acc, el); stop {
+			return acc, nil
+		}
+	}
+}
+
 // Any returns the first element in Seq for which fn returns true, or nil. The
 // returned boolean indicates whether or not a matching element was found.
 // Completes in O(N) time.
 func Any(fn func(el interface{}) bool, s Seq) (interface{}, bool) {
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); ok {
-			if fn(el) {
-				return el, true
-			}
-		} else {
-			return nil, false
+	for el := range Values(s) {
+		if fn(el) {
+			return el, true
 		}
 	}
+	return nil, false
 }
 
 // All returns true if fn returns true for all elements in the Seq. Completes in
 // O(N) time.
 func All(fn func(interface{}) bool, s Seq) bool {
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); ok {
-			if !fn(el) {
-				return false
-			}
-		} else {
-			return true
+	for el := range Values(s) {
+		if !fn(el) {
+			return false
 		}
 	}
+	return true
 }
 
 // Filter returns a Seq containing all elements in the given Seq for which fn
 // returned true. Completes in O(N) time.
 func Filter(fn func(el interface{}) bool, s Seq) Seq {
 	l := NewList()
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); ok {
-			if fn(el) {
-				l = l.Prepend(el)
-			}
-		} else {
-			return Reverse(l)
+	for el := range Values(s) {
+		if fn(el) {
+			l = l.Prepend(el)
 		}
 	}
+	return Reverse(l)
 }
 
 // Flatten flattens the given Seq into a single, one-dimensional Seq. This
@@ -209,19 +211,14 @@ func Filter(fn func(el interface{}) bool, s Seq) Seq {
 // actually are.
 func Flatten(s Seq) Seq {
 	l := NewList()
-	var el interface{}
-	var ok bool
-	for {
-		if el, s, ok = s.FirstRest(); ok {
-			if els, ok := el.(Seq); ok {
-				l = l.PrependSeq(Reverse(els))
-			} else {
-				l = l.Prepend(el)
-			}
+	for el := range Values(s) {
+		if els, ok := el.(Seq); ok {
+			l = l.PrependSeq(Reverse(els))
 		} else {
-			return Reverse(l)
+			l = l.Prepend(el)
 		}
 	}
+	return Reverse(l)
 }
 
 // Take returns a Seq containing the first n elements in the given Seq. If n is
@@ -229,14 +226,15 @@ func Flatten(s Seq) Seq {
 // Completes in O(N) time.
 func Take(n uint64, s Seq) Seq {
 	l := NewList()
-	var el interface{}
-	var ok bool
-	for i := uint64(0); i < n; i++ {
-		el, s, ok = s.FirstRest()
-		if !ok {
+	if n == 0 {
+		return l
+	}
+	i := uint64(0)
+	for el := range Values(s) {
+		l = l.Prepend(el)
+		if i++; i == n {
 			break
 		}
-		l = l.Prepend(el)
 	}
 	return Reverse(l)
 }
@@ -246,11 +244,8 @@ func Take(n uint64, s Seq) Seq {
 // Completes in O(N) time.
 func TakeWhile(pred func(interface{}) bool, s Seq) Seq {
 	l := NewList()
-	var el interface{}
-	var ok bool
-	for {
-		el, s, ok = s.FirstRest()
-		if !ok || !pred(el) {
+	for el := range Values(s) {
+		if !pred(el) {
 			break
 		}
 		l = l.Prepend(el)
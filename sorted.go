@@ -0,0 +1,580 @@
+package seq
+
+// SortedSet and SortedMap are built on a persistent AVL tree. Like with Set and
+// HashMap, SortedMap is a SortedSet of KVs whose comparator only looks at the
+// keys.
+
+// CompareFn is used to order the elements of a SortedSet or the keys of a
+// SortedMap. It returns a negative number if a sorts before b, a positive number
+// if a sorts after b, and zero if the two are equal, in which case only one of
+// them may be in the SortedSet at a time.
+type CompareFn func(a, b interface{}) int
+
+type avlNode struct {
+	val         interface{}
+	left, right *avlNode
+	height      int
+}
+
+func (n *avlNode) h() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func newAVLNode(val interface{}, left, right *avlNode) *avlNode {
+	h := left.h()
+	if rh := right.h(); rh > h {
+		h = rh
+	}
+	return &avlNode{val, left, right, h + 1}
+}
+
+// Returns a new node with the given value and children, rotating if necessary
+// to keep the tree balanced. The children's heights must differ by no more than
+// two.
+func avlBalance(val interface{}, left, right *avlNode) *avlNode {
+	lh, rh := left.h(), right.h()
+	if lh > rh+1 {
+		if left.left.h() >= left.right.h() {
+			return newAVLNode(left.val, left.left, newAVLNode(val, left.right, right))
+		}
+		lr := left.right
+		return newAVLNode(
+			lr.val,
+			newAVLNode(left.val, left.left, lr.left),
+			newAVLNode(val, lr.right, right),
+		)
+	} else if rh > lh+1 {
+		if right.right.h() >= right.left.h() {
+			return newAVLNode(right.val, newAVLNode(val, left, right.left), right.right)
+		}
+		rl := right.left
+		return newAVLNode(
+			rl.val,
+			newAVLNode(val, left, rl.left),
+			newAVLNode(right.val, rl.right, right.right),
+		)
+	}
+	return newAVLNode(val, left, right)
+}
+
+func (n *avlNode) set(cmp CompareFn, val interface{}) (*avlNode, bool) {
+	if n == nil {
+		return newAVLNode(val, nil, nil), true
+	}
+	c := cmp(val, n.val)
+	if c < 0 {
+		nl, ok := n.left.set(cmp, val)
+		return avlBalance(n.val, nl, n.right), ok
+	} else if c > 0 {
+		nr, ok := n.right.set(cmp, val)
+		return avlBalance(n.val, n.left, nr), ok
+	}
+	return newAVLNode(val, n.left, n.right), false
+}
+
+func (n *avlNode) min() *avlNode {
+	for n != nil && n.left != nil {
+		n = n.left
+	}
+	return n
+}
+
+func (n *avlNode) max() *avlNode {
+	for n != nil && n.right != nil {
+		n = n.right
+	}
+	return n
+}
+
+// Calls yield on each value in the tree in order, stopping and returning false
+// if yield returns false
+func (n *avlNode) each(yield func(interface{}) bool) bool {
+	return n == nil || (n.left.each(yield) && yield(n.val) && n.right.each(yield))
+}
+
+func (n *avlNode) delMin() *avlNode {
+	if n.left == nil {
+		return n.right
+	}
+	return avlBalance(n.val, n.left.delMin(), n.right)
+}
+
+func (n *avlNode) del(cmp CompareFn, val interface{}) (*avlNode, bool) {
+	if n == nil {
+		return nil, false
+	}
+	c := cmp(val, n.val)
+	if c < 0 {
+		nl, ok := n.left.del(cmp, val)
+		if !ok {
+			return n, false
+		}
+		return avlBalance(n.val, nl, n.right), true
+	} else if c > 0 {
+		nr, ok := n.right.del(cmp, val)
+		if !ok {
+			return n, false
+		}
+		return avlBalance(n.val, n.left, nr), true
+	} else if n.left == nil {
+		return n.right, true
+	} else if n.right == nil {
+		return n.left, true
+	}
+	return avlBalance(n.right.min().val, n.left, n.right.delMin()), true
+}
+
+func (n *avlNode) get(cmp CompareFn, val interface{}) *avlNode {
+	for n != nil {
+		c := cmp(val, n.val)
+		if c < 0 {
+			n = n.left
+		} else if c > 0 {
+			n = n.right
+		} else {
+			return n
+		}
+	}
+	return nil
+}
+
+// Returns the node with the greatest value less than or equal to val
+func (n *avlNode) floor(cmp CompareFn, val interface{}) *avlNode {
+	var best *avlNode
+	for n != nil {
+		c := cmp(val, n.val)
+		if c < 0 {
+			n = n.left
+		} else if c > 0 {
+			best, n = n, n.right
+		} else {
+			return n
+		}
+	}
+	return best
+}
+
+// Returns the node with the least value greater than or equal to val
+func (n *avlNode) ceiling(cmp CompareFn, val interface{}) *avlNode {
+	var best *avlNode
+	for n != nil {
+		c := cmp(val, n.val)
+		if c < 0 {
+			best, n = n, n.left
+		} else if c > 0 {
+			n = n.right
+		} else {
+			return n
+		}
+	}
+	return best
+}
+
+// sortedSeq is a Seq over the nodes of an AVL tree, in order. stack holds the
+// nodes whose value and (for ascending order) right subtree are still to be
+// visited, with the next node on top.
+type sortedSeq struct {
+	stack *List
+	desc  bool
+
+	// If cmp is set then iteration stops at the first value which is not less
+	// than hi
+	cmp CompareFn
+	hi  interface{}
+}
+
+// Pushes n and the nodes down its left (or right, if desc) side onto stack
+func (ss *sortedSeq) pushSide(stack *List, n *avlNode) *List {
+	for n != nil {
+		stack = stack.Prepend(n)
+		if ss.desc {
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return stack
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. Completes in
+// O(log(N)) time, and amortized O(1) time over the whole Seq.
+func (ss *sortedSeq) FirstRest() (interface{}, Seq, bool) {
+	if ss.stack == nil {
+		return nil, ss, false
+	}
+	n := ss.stack.el.(*avlNode)
+	if ss.cmp != nil && ss.cmp(n.val, ss.hi) >= 0 {
+		return nil, ss, false
+	}
+	next := n.right
+	if ss.desc {
+		next = n.left
+	}
+	rest := *ss
+	rest.stack = ss.pushSide(ss.stack.next, next)
+	return n.val, &rest, true
+}
+
+// String is an implementation of String for Stringer interface
+func (ss *sortedSeq) String() string {
+	return ToString(ss, "(", ")")
+}
+
+// SortedSet is an implementation of Seq in the form of a persistent balanced
+// binary tree, whose elements are kept in the order given by a CompareFn. As
+// with Set, all operations leave the original SortedSet intact and share as
+// many of its nodes as possible. Iterating over a SortedSet yields its
+// elements in ascending order.
+//
+// SortedSets must be created with NewSortedSet, so they know their CompareFn. A
+// nil *SortedSet can be read from as an empty SortedSet, but not added to.
+type SortedSet struct {
+	cmp  CompareFn
+	root *avlNode
+	size uint64
+}
+
+// NewSortedSet returns a new SortedSet, ordered by the given CompareFn, of the
+// given elements (or no elements, for an empty SortedSet)
+func NewSortedSet(cmp CompareFn, vals ...interface{}) *SortedSet {
+	set := &SortedSet{cmp: cmp}
+	for i := range vals {
+		set, _ = set.SetVal(vals[i])
+	}
+	return set
+}
+
+// Size returns the number of elements in the SortedSet. Completes in O(1)
+// time.
+func (set *SortedSet) Size() uint64 {
+	if set == nil {
+		return 0
+	}
+	return set.size
+}
+
+func (set *SortedSet) rootNode() *avlNode {
+	if set == nil {
+		return nil
+	}
+	return set.root
+}
+
+// SetVal returns a new SortedSet with the given value added to it. Also
+// returns whether or not this is the first time setting this value (false if
+// it was already there and was overwritten). Completes in O(log(N)) time.
+func (set *SortedSet) SetVal(val interface{}) (*SortedSet, bool) {
+	nroot, ok := set.root.set(set.cmp, val)
+	nset := &SortedSet{set.cmp, nroot, set.size}
+	if ok {
+		nset.size++
+	}
+	return nset, ok
+}
+
+// DelVal returns a new SortedSet with the given value removed from it and
+// whether or not the value was actually removed. Completes in O(log(N)) time.
+func (set *SortedSet) DelVal(val interface{}) (*SortedSet, bool) {
+	if set == nil {
+		return set, false
+	}
+	nroot, ok := set.root.del(set.cmp, val)
+	if !ok {
+		return set, false
+	}
+	return &SortedSet{set.cmp, nroot, set.size - 1}, true
+}
+
+// GetVal returns a value from the SortedSet, along with a boolean indicating
+// whether or not the value was found. Completes in O(log(N)) time.
+func (set *SortedSet) GetVal(val interface{}) (interface{}, bool) {
+	if set == nil {
+		return nil, false
+	}
+	return nodeVal(set.root.get(set.cmp, val))
+}
+
+func nodeVal(n *avlNode) (interface{}, bool) {
+	if n == nil {
+		return nil, false
+	}
+	return n.val, true
+}
+
+// Min returns the smallest element in the SortedSet, or false if it's empty.
+// Completes in O(log(N)) time.
+func (set *SortedSet) Min() (interface{}, bool) {
+	return nodeVal(set.rootNode().min())
+}
+
+// Max returns the largest element in the SortedSet, or false if it's empty.
+// Completes in O(log(N)) time.
+func (set *SortedSet) Max() (interface{}, bool) {
+	return nodeVal(set.rootNode().max())
+}
+
+// Floor returns the largest element in the SortedSet which is less than or
+// equal to the given value, or false if there isn't one. Completes in
+// O(log(N)) time.
+func (set *SortedSet) Floor(val interface{}) (interface{}, bool) {
+	if set == nil {
+		return nil, false
+	}
+	return nodeVal(set.root.floor(set.cmp, val))
+}
+
+// Ceiling returns the smallest element in the SortedSet which is greater than
+// or equal to the given value, or false if there isn't one. Completes in
+// O(log(N)) time.
+func (set *SortedSet) Ceiling(val interface{}) (interface{}, bool) {
+	if set == nil {
+		return nil, false
+	}
+	return nodeVal(set.root.ceiling(set.cmp, val))
+}
+
+// Range returns a Seq of all elements in the SortedSet which are greater than
+// or equal to lo and less than hi, in ascending order. Creating the Seq
+// completes in O(log(N)) time, iterating over it in O(M) time, M being the
+// number of elements in the range.
+func (set *SortedSet) Range(lo, hi interface{}) Seq {
+	if set == nil {
+		return &sortedSeq{}
+	}
+	ss := &sortedSeq{cmp: set.cmp, hi: hi}
+	for n := set.root; n != nil; {
+		if set.cmp(n.val, lo) >= 0 {
+			ss.stack = ss.stack.Prepend(n)
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return ss
+}
+
+// Descending returns a Seq of all elements in the SortedSet in descending
+// order. Completes in O(log(N)) time.
+func (set *SortedSet) Descending() Seq {
+	ss := &sortedSeq{desc: true}
+	ss.stack = ss.pushSide(nil, set.rootNode())
+	return ss
+}
+
+// Returns a sortedSeq of all elements in the SortedSet in ascending order
+func (set *SortedSet) ascending() *sortedSeq {
+	ss := &sortedSeq{}
+	ss.stack = ss.pushSide(nil, set.rootNode())
+	return ss
+}
+
+// Calls yield on each element of the SortedSet in ascending order, stopping if
+// it returns false. Used by Values, so that iterating over a SortedSet doesn't
+// build a new SortedSet for each element the way FirstRest does.
+func (set *SortedSet) each(yield func(interface{}) bool) {
+	set.rootNode().each(yield)
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. The first
+// element is always the smallest, and the rest is the SortedSet without it.
+// Completes in O(log(N)) time.
+func (set *SortedSet) FirstRest() (interface{}, Seq, bool) {
+	if set.Size() == 0 {
+		return nil, set, false
+	}
+	return set.root.min().val, &SortedSet{set.cmp, set.root.delMin(), set.size - 1}, true
+}
+
+// Hash implements the Hash method for the Setable interface
+func (set *SortedSet) Hash(i uint32) uint32 {
+	sum := uint32(0)
+	set.each(func(el interface{}) bool {
+		sum += hash(el, i)
+		return true
+	})
+	return sum
+}
+
+// Equal implements the Equal method for the Comparable and Setable interfaces.
+// Two SortedSets are equal if they have equal elements in the same order.
+func (set *SortedSet) Equal(v interface{}) bool {
+	set2, ok := v.(*SortedSet)
+	if !ok || set.Size() != set2.Size() {
+		return false
+	}
+
+	var s, s2 Seq = set.ascending(), set2.ascending()
+	var el, el2 interface{}
+	for {
+		el, s, ok = s.FirstRest()
+		el2, s2, _ = s2.FirstRest()
+		if !ok {
+			return true
+		} else if !equal(el, el2) {
+			return false
+		}
+	}
+}
+
+// String is an implementation of String for Stringer interface
+func (set *SortedSet) String() string {
+	return ToString(set, "#{", "}#")
+}
+
+// SortedMap is a key/value store built on top of a SortedSet, the same way
+// HashMap is built on top of a Set. Its keys are ordered by a CompareFn, and
+// iterating over it yields KVs in ascending key order.
+//
+// SortedMaps must be created with NewSortedMap, so they know their CompareFn. A
+// nil *SortedMap can be read from as an empty SortedMap, but not added to.
+type SortedMap struct {
+	set *SortedSet
+}
+
+func kvCompareFn(cmp CompareFn) CompareFn {
+	return func(a, b interface{}) int {
+		return cmp(a.(*KV).Key, b.(*KV).Key)
+	}
+}
+
+// NewSortedMap returns a new SortedMap, with keys ordered by the given
+// CompareFn, of the given KVs (or possibly just an empty SortedMap)
+func NewSortedMap(cmp CompareFn, kvs ...*KV) *SortedMap {
+	sm := &SortedMap{&SortedSet{cmp: kvCompareFn(cmp)}}
+	for i := range kvs {
+		sm, _ = sm.Set(kvs[i].Key, kvs[i].Val)
+	}
+	return sm
+}
+
+func (sm *SortedMap) sset() *SortedSet {
+	if sm == nil {
+		return nil
+	}
+	return sm.set
+}
+
+// Size returns the number of KVs in the SortedMap. Completes in O(1) time.
+func (sm *SortedMap) Size() uint64 {
+	return sm.sset().Size()
+}
+
+// Set returns a new SortedMap with the given value set on the given key. Also
+// returns whether or not this was the first time setting that key (false if it
+// was already there and was overwritten). Completes in O(log(N)) time.
+func (sm *SortedMap) Set(key, val interface{}) (*SortedMap, bool) {
+	nset, ok := sm.set.SetVal(KeyVal(key, val))
+	return &SortedMap{nset}, ok
+}
+
+// Del returns a new SortedMap with the given key removed from it. Also returns
+// whether or not the key was already there (true if so, false if not).
+// Completes in O(log(N)) time.
+func (sm *SortedMap) Del(key interface{}) (*SortedMap, bool) {
+	nset, ok := sm.sset().DelVal(KeyVal(key, nil))
+	if !ok {
+		return sm, false
+	}
+	return &SortedMap{nset}, true
+}
+
+func kvVal(v interface{}, ok bool) (*KV, bool) {
+	if !ok {
+		return nil, false
+	}
+	return v.(*KV), true
+}
+
+// Get returns a value for a given key from the SortedMap, along with a boolean
+// indicating whether or not the value was found. Completes in O(log(N)) time.
+func (sm *SortedMap) Get(key interface{}) (interface{}, bool) {
+	if kv, ok := kvVal(sm.sset().GetVal(KeyVal(key, nil))); ok {
+		return kv.Val, true
+	}
+	return nil, false
+}
+
+// Min returns the KV with the smallest key in the SortedMap, or false if it's
+// empty. Completes in O(log(N)) time.
+func (sm *SortedMap) Min() (*KV, bool) {
+	return kvVal(sm.sset().Min())
+}
+
+// Max returns the KV with the largest key in the SortedMap, or false if it's
+// empty. Completes in O(log(N)) time.
+func (sm *SortedMap) Max() (*KV, bool) {
+	return kvVal(sm.sset().Max())
+}
+
+// Floor returns the KV with the largest key which is less than or equal to the
+// given one, or false if there isn't one. Completes in O(log(N)) time.
+func (sm *SortedMap) Floor(key interface{}) (*KV, bool) {
+	return kvVal(sm.sset().Floor(KeyVal(key, nil)))
+}
+
+// Ceiling returns the KV with the smallest key which is greater than or equal
+// to the given one, or false if there isn't one. Completes in O(log(N)) time.
+func (sm *SortedMap) Ceiling(key interface{}) (*KV, bool) {
+	return kvVal(sm.sset().Ceiling(KeyVal(key, nil)))
+}
+
+// Range returns a Seq of all KVs in the SortedMap whose keys are greater than or
+// equal to lo and less than hi, in ascending key order. Has the same
+// complexity as SortedSet's Range method.
+func (sm *SortedMap) Range(lo, hi interface{}) Seq {
+	return sm.sset().Range(KeyVal(lo, nil), KeyVal(hi, nil))
+}
+
+// Descending returns a Seq of all KVs in the SortedMap in descending key
+// order. Completes in O(log(N)) time.
+func (sm *SortedMap) Descending() Seq {
+	return sm.sset().Descending()
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. First return
+// value will always be the *KV with the smallest key, or nil, and the rest is
+// the SortedMap without it. Completes in O(log(N)) time.
+func (sm *SortedMap) FirstRest() (interface{}, Seq, bool) {
+	el, rest, ok := sm.sset().FirstRest()
+	if !ok {
+		return nil, sm, false
+	}
+	return el, &SortedMap{rest.(*SortedSet)}, true
+}
+
+// Hash implements the Hash method for the Setable interface
+func (sm *SortedMap) Hash(i uint32) uint32 {
+	return sm.sset().Hash(i)
+}
+
+// Equal implements the Equal method for the Comparable and Setable interfaces.
+// Two SortedMaps are equal if they have the same keys, in the same order, with
+// equal values.
+func (sm *SortedMap) Equal(v interface{}) bool {
+	sm2, ok := v.(*SortedMap)
+	if !ok || sm.Size() != sm2.Size() {
+		return false
+	}
+
+	var s, s2 Seq = sm.sset().ascending(), sm2.sset().ascending()
+	var el, el2 interface{}
+	for {
+		el, s, ok = s.FirstRest()
+		el2, s2, _ = s2.FirstRest()
+		if !ok {
+			return true
+		}
+		kv, kv2 := el.(*KV), el2.(*KV)
+		if !equal(kv.Key, kv2.Key) || !equal(kv.Val, kv2.Val) {
+			return false
+		}
+	}
+}
+
+// String is an implementation of String for Stringer interface
+func (sm *SortedMap) String() string {
+	return ToString(sm, "{", "}")
+}
@@ -0,0 +1,221 @@
+package seq
+
+import (
+	"math/rand"
+	"sort"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func compareInts(a, b interface{}) int {
+	return a.(int) - b.(int)
+}
+
+// Asserts that the given AVL tree is balanced and ordered, and returns its size
+func assertSaneAVL(t *T, n *avlNode) uint64 {
+	if n == nil {
+		return 0
+	}
+	assert.Equal(t, true, n.left.h()-n.right.h() <= 1)
+	assert.Equal(t, true, n.right.h()-n.left.h() <= 1)
+	assert.Equal(t, n.height, newAVLNode(nil, n.left, n.right).height)
+	if n.left != nil {
+		assert.Equal(t, true, n.left.max().val.(int) < n.val.(int))
+	}
+	if n.right != nil {
+		assert.Equal(t, true, n.right.min().val.(int) > n.val.(int))
+	}
+	return 1 + assertSaneAVL(t, n.left) + assertSaneAVL(t, n.right)
+}
+
+// Test creating a SortedSet and calling the Seq interface methods on it
+func TestSortedSetSeq(t *T) {
+	s := NewSortedSet(compareInts, 3, 1, 2, 5, 4)
+	testSeqGen(t, s, []interface{}{1, 2, 3, 4, 5})
+	assert.Equal(t, []interface{}{5, 4, 3, 2, 1}, ToSlice(s.Descending()))
+
+	empty := NewSortedSet(compareInts)
+	assert.Equal(t, uint64(0), Size(empty))
+	assert.Equal(t, 0, len(ToSlice(empty)))
+	assert.Equal(t, 0, len(ToSlice(empty.Descending())))
+
+	// Existing helpers work on it
+	assert.Equal(t, []interface{}{1, 2}, ToSlice(Take(2, s)))
+	even := func(el interface{}) bool { return el.(int)%2 == 0 }
+	assert.Equal(t, []interface{}{2, 4}, ToSlice(LFilter(even, s)))
+
+	// The rest returned by FirstRest is itself a SortedSet, which can still be
+	// added to
+	el, rest, ok := s.FirstRest()
+	assert.Equal(t, 1, el)
+	assert.Equal(t, true, ok)
+	rs := rest.(*SortedSet)
+	assert.Equal(t, uint64(4), Size(rs))
+	assertSaneAVL(t, rs.root)
+	rs, _ = rs.SetVal(0)
+	assert.Equal(t, []interface{}{0, 2, 3, 4, 5}, ToSlice(rs))
+	_, rest, _ = NewSortedSet(compareInts, 1).FirstRest()
+	_, rest2, ok := rest.FirstRest()
+	assert.Equal(t, false, ok)
+	assert.Equal(t, rest, rest2)
+}
+
+// Test setting and deleting many values on a SortedSet, checking that it stays
+// balanced and sorted, and that old versions are unaffected
+func TestSortedSetSetDel(t *T) {
+	r := rand.New(rand.NewSource(1))
+	s := NewSortedSet(compareInts)
+	m := map[int]bool{}
+	for i := 0; i < 2000; i++ {
+		v := r.Intn(500)
+		prev, prevSize := s, s.Size()
+		var ok bool
+		if r.Intn(3) == 0 {
+			s, ok = s.DelVal(v)
+			assert.Equal(t, m[v], ok)
+			delete(m, v)
+		} else {
+			s, ok = s.SetVal(v)
+			assert.Equal(t, !m[v], ok)
+			m[v] = true
+		}
+		assert.Equal(t, prevSize, assertSaneAVL(t, prev.root))
+		assert.Equal(t, uint64(len(m)), s.Size())
+	}
+	assert.Equal(t, s.Size(), assertSaneAVL(t, s.root))
+
+	exp := make([]interface{}, 0, len(m))
+	for i := 0; i < 500; i++ {
+		if m[i] {
+			exp = append(exp, i)
+		}
+	}
+	assert.Equal(t, exp, ToSlice(s))
+}
+
+// Test the ordered lookup methods on a SortedSet
+func TestSortedSetLookups(t *T) {
+	s := NewSortedSet(compareInts, 10, 20, 30, 40, 50)
+
+	assertLookup := func(exp interface{}, expOk bool, v interface{}, ok bool) {
+		assert.Equal(t, exp, v)
+		assert.Equal(t, expOk, ok)
+	}
+
+	v, ok := s.Min()
+	assertLookup(10, true, v, ok)
+	v, ok = s.Max()
+	assertLookup(50, true, v, ok)
+	v, ok = s.GetVal(30)
+	assertLookup(30, true, v, ok)
+	v, ok = s.GetVal(35)
+	assertLookup(nil, false, v, ok)
+
+	v, ok = s.Floor(35)
+	assertLookup(30, true, v, ok)
+	v, ok = s.Floor(30)
+	assertLookup(30, true, v, ok)
+	v, ok = s.Floor(5)
+	assertLookup(nil, false, v, ok)
+	v, ok = s.Ceiling(35)
+	assertLookup(40, true, v, ok)
+	v, ok = s.Ceiling(40)
+	assertLookup(40, true, v, ok)
+	v, ok = s.Ceiling(55)
+	assertLookup(nil, false, v, ok)
+
+	var nilset *SortedSet
+	v, ok = nilset.Min()
+	assertLookup(nil, false, v, ok)
+	v, ok = nilset.Floor(1)
+	assertLookup(nil, false, v, ok)
+}
+
+// Test ranging over a SortedSet
+func TestSortedSetRange(t *T) {
+	ints := make([]interface{}, 100)
+	for i := range ints {
+		ints[i] = i * 2
+	}
+	perm := rand.New(rand.NewSource(1)).Perm(len(ints))
+	s := NewSortedSet(compareInts)
+	for _, i := range perm {
+		s, _ = s.SetVal(ints[i])
+	}
+
+	assert.Equal(t, ints[5:10], ToSlice(s.Range(10, 20)))
+	assert.Equal(t, ints[5:10], ToSlice(s.Range(9, 19)))
+	assert.Equal(t, ints, ToSlice(s.Range(-1, 1000)))
+	assert.Equal(t, ints[99:], ToSlice(s.Range(198, 1000)))
+	assert.Equal(t, 0, len(ToSlice(s.Range(20, 20))))
+	assert.Equal(t, 0, len(ToSlice(s.Range(1000, 2000))))
+	assert.Equal(t, 0, len(ToSlice(NewSortedSet(compareInts).Range(0, 10))))
+}
+
+// Test that two SortedSets compare equality correctly
+func TestSortedSetEqual(t *T) {
+	s1 := NewSortedSet(compareInts, 1, 2, 3)
+	s2 := NewSortedSet(compareInts, 3, 2, 1)
+	assert.Equal(t, true, s1.Equal(s2))
+	s2, _ = s2.DelVal(2)
+	assert.Equal(t, false, s1.Equal(s2))
+	assert.Equal(t, false, s1.Equal(NewSet(1, 2, 3)))
+}
+
+// Test the SortedMap methods
+func TestSortedMap(t *T) {
+	keys := []int{5, 3, 8, 1, 4, 7, 9, 2, 6}
+	m := NewSortedMap(compareInts)
+	for _, k := range keys {
+		m, _ = m.Set(k, k*10)
+	}
+	m2, ok := m.Set(5, "five")
+	assert.Equal(t, false, ok)
+	assert.Equal(t, uint64(9), m2.Size())
+
+	v, ok := m.Get(5)
+	assert.Equal(t, 50, v)
+	assert.Equal(t, true, ok)
+	v, _ = m2.Get(5)
+	assert.Equal(t, "five", v)
+
+	sorted := append([]int{}, keys...)
+	sort.Ints(sorted)
+	var gotKeys []int
+	for _, el := range ToSlice(m) {
+		gotKeys = append(gotKeys, el.(*KV).Key.(int))
+	}
+	assert.Equal(t, sorted, gotKeys)
+
+	kv, ok := m.Min()
+	assert.Equal(t, KeyVal(1, 10), kv)
+	kv, _ = m.Max()
+	assert.Equal(t, KeyVal(9, 90), kv)
+	kv, _ = m.Floor(0)
+	assert.Equal(t, (*KV)(nil), kv)
+	kv, _ = m.Ceiling(0)
+	assert.Equal(t, KeyVal(1, 10), kv)
+
+	kvs := ToSlice(m.Range(3, 6))
+	assert.Equal(t, []interface{}{KeyVal(3, 30), KeyVal(4, 40), KeyVal(5, 50)}, kvs)
+	first, _, _ := m.Descending().FirstRest()
+	assert.Equal(t, KeyVal(9, 90), first)
+	first, rest, _ := m.FirstRest()
+	assert.Equal(t, KeyVal(1, 10), first)
+	assert.Equal(t, uint64(8), rest.(*SortedMap).Size())
+
+	m3, ok := m.Del(5)
+	assert.Equal(t, true, ok)
+	_, ok = m3.Get(5)
+	assert.Equal(t, false, ok)
+	_, ok = m3.Del(5)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, uint64(8), m3.Size())
+	assert.Equal(t, uint64(9), m.Size())
+
+	assert.Equal(t, false, m.Equal(m2))
+	m2, _ = m2.Set(5, 50)
+	assert.Equal(t, true, m.Equal(m2))
+	assert.Equal(t, "{ 1 -> 10 2 -> 20 3 -> 30 }", NewSortedMap(compareInts, KeyVal(3, 30), KeyVal(1, 10), KeyVal(2, 20)).String())
+}
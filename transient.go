@@ -0,0 +1,266 @@
+package seq
+
+// Transients are mutable versions of the persistent types, for building them
+// up or changing them in bulk. A transient starts out sharing all of its nodes
+// with the persistent value it was made from, and copies a node the first time
+// it changes it. Nodes it has copied or created are marked with its editToken,
+// and from then on it changes them in place. Calling Persistent freezes the
+// editToken, so nothing can change those nodes again, and hands them back as
+// a normal persistent value.
+
+// An editToken marks the nodes which a transient owns
+type editToken struct {
+	frozen bool
+}
+
+// Panics if the transient owning the editToken has been made persistent
+func (edit *editToken) check(name string) {
+	if edit.frozen {
+		panic(name + " used after call to Persistent")
+	}
+}
+
+// TransientSet is a mutable version of a Set. Each change is made in place, so
+// adding many values to a TransientSet allocates far less than adding them to
+// a Set one at a time. The Set the TransientSet was made from is never changed.
+//
+// A TransientSet is not safe for use by multiple go-routines at once. Once
+// Persistent has been called on it any further use of it will panic.
+type TransientSet struct {
+	edit *editToken
+	root *setNode
+}
+
+// Transient returns a TransientSet holding the same values as the Set.
+// Completes in O(1) time.
+func (set *Set) Transient() *TransientSet {
+	return &TransientSet{edit: new(editToken), root: set.rootNode()}
+}
+
+// Persistent returns a Set holding the TransientSet's values, and freezes the
+// TransientSet so that it can't be used anymore. Completes in O(1) time.
+func (t *TransientSet) Persistent() *Set {
+	t.edit.check("TransientSet")
+	t.edit.frozen = true
+	if t.root == nil {
+		return nil
+	}
+	return &Set{t.root}
+}
+
+// Size returns the number of values in the TransientSet. Completes in O(1)
+// time.
+func (t *TransientSet) Size() uint64 {
+	t.edit.check("TransientSet")
+	if t.root == nil {
+		return 0
+	}
+	return t.root.size
+}
+
+// SetVal adds the given value to the TransientSet. Returns whether or not this
+// is the first time setting this value (false if it was already there and was
+// overwritten).
+func (t *TransientSet) SetVal(val interface{}) bool {
+	ok, err := t.TrySetVal(val)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
+// TrySetVal is like SetVal, but returns an ErrUnhashable if the value can't be
+// hashed, rather than panicking
+func (t *TransientSet) TrySetVal(val interface{}) (bool, error) {
+	t.edit.check("TransientSet")
+	h, err := tryHash(val)
+	if err != nil {
+		return false, err
+	}
+
+	if t.root == nil {
+		t.root = &setNode{edit: t.edit}
+	}
+	var ok bool
+	t.root, ok = t.root.set(setEntry{val: val, h: h}, 0, t.edit)
+	return ok, nil
+}
+
+// DelVal removes the given value from the TransientSet, and returns whether or
+// not it was there.
+func (t *TransientSet) DelVal(val interface{}) bool {
+	ok, err := t.TryDelVal(val)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
+// TryDelVal is like DelVal, but returns an ErrUnhashable if the value can't be
+// hashed, rather than panicking
+func (t *TransientSet) TryDelVal(val interface{}) (bool, error) {
+	t.edit.check("TransientSet")
+	h, err := tryHash(val)
+	if err != nil || t.root == nil {
+		return false, err
+	}
+
+	root, ok := t.root.del(val, h, 0, t.edit)
+	if !ok {
+		return false, nil
+	} else if len(root.entries) == 0 {
+		root = nil
+	}
+	t.root = root
+	return true, nil
+}
+
+// GetVal returns a value from the TransientSet, along with a boolean
+// indicating whether or not the value was found.
+func (t *TransientSet) GetVal(val interface{}) (interface{}, bool) {
+	t.edit.check("TransientSet")
+	h := hash(val, 0)
+	if t.root == nil {
+		return nil, false
+	}
+	return t.root.get(val, h, 0)
+}
+
+// TransientHashMap is a mutable version of a HashMap, with the same properties
+// as TransientSet.
+type TransientHashMap struct {
+	set *TransientSet
+}
+
+// Transient returns a TransientHashMap holding the same KVs as the HashMap.
+// Completes in O(1) time.
+func (hm *HashMap) Transient() *TransientHashMap {
+	var set *Set
+	if hm != nil {
+		set = hm.set
+	}
+	return &TransientHashMap{set.Transient()}
+}
+
+// Persistent returns a HashMap holding the TransientHashMap's KVs, and freezes
+// the TransientHashMap so that it can't be used anymore. Completes in O(1)
+// time.
+func (t *TransientHashMap) Persistent() *HashMap {
+	t.set.edit.check("TransientHashMap")
+	return &HashMap{t.set.Persistent()}
+}
+
+// Size returns the number of KVs in the TransientHashMap. Completes in O(1)
+// time.
+func (t *TransientHashMap) Size() uint64 {
+	t.set.edit.check("TransientHashMap")
+	return t.set.Size()
+}
+
+// Set sets the given value on the given key in the TransientHashMap. Returns
+// whether or not this was the first time setting that key (false if it was
+// already there and was overwritten).
+func (t *TransientHashMap) Set(key, val interface{}) bool {
+	ok, err := t.TrySet(key, val)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
+// TrySet is like Set, but returns an ErrUnhashable if the key can't be hashed,
+// rather than panicking
+func (t *TransientHashMap) TrySet(key, val interface{}) (bool, error) {
+	t.set.edit.check("TransientHashMap")
+	return t.set.TrySetVal(KeyVal(key, val))
+}
+
+// Del removes the given key from the TransientHashMap, and returns whether or
+// not it was there.
+func (t *TransientHashMap) Del(key interface{}) bool {
+	ok, err := t.TryDel(key)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
+// TryDel is like Del, but returns an ErrUnhashable if the key can't be hashed,
+// rather than panicking
+func (t *TransientHashMap) TryDel(key interface{}) (bool, error) {
+	t.set.edit.check("TransientHashMap")
+	return t.set.TryDelVal(KeyVal(key, nil))
+}
+
+// Get returns a value for a given key from the TransientHashMap, along with a
+// boolean indicating whether or not the value was found.
+func (t *TransientHashMap) Get(key interface{}) (interface{}, bool) {
+	t.set.edit.check("TransientHashMap")
+	if kv, ok := t.set.GetVal(KeyVal(key, nil)); ok {
+		return kv.(*KV).Val, true
+	}
+	return nil, false
+}
+
+// TransientList is a mutable version of a List. Prepending to a List is already
+// cheap, but appending to one copies the whole List. A TransientList only
+// copies the List it was made from on its first Append, and after that
+// appends in O(1) time. The List the TransientList was made from is never
+// changed.
+//
+// A TransientList is not safe for use by multiple go-routines at once. Once
+// Persistent has been called on it any further use of it will panic.
+type TransientList struct {
+	edit *editToken
+	head *List
+
+	// The last cell of head, if the TransientList created it and so may change
+	// it in place
+	tail *List
+}
+
+// Transient returns a TransientList holding the same elements as the List.
+// Completes in O(1) time.
+func (l *List) Transient() *TransientList {
+	return &TransientList{edit: new(editToken), head: l}
+}
+
+// Persistent returns a List holding the TransientList's elements, and freezes
+// the TransientList so that it can't be used anymore. Completes in O(1) time.
+func (tl *TransientList) Persistent() *List {
+	tl.edit.check("TransientList")
+	tl.edit.frozen = true
+	return tl.head
+}
+
+// Prepend adds the given element to the front of the TransientList. Completes
+// in O(1) time.
+func (tl *TransientList) Prepend(el interface{}) {
+	tl.edit.check("TransientList")
+	tl.head = &List{el, tl.head}
+	if tl.head.next == nil {
+		tl.tail = tl.head
+	}
+}
+
+// Append adds the given element to the end of the TransientList. The first
+// Append to a TransientList made from a non-empty List completes in O(N) time,
+// every one after that in O(1).
+func (tl *TransientList) Append(el interface{}) {
+	tl.edit.check("TransientList")
+	cell := &List{el, nil}
+	if tl.head == nil {
+		tl.head, tl.tail = cell, cell
+		return
+	} else if tl.tail == nil {
+		orig := tl.head
+		tl.head = &List{orig.el, nil}
+		tl.tail = tl.head
+		for cur := orig.next; cur != nil; cur = cur.next {
+			tl.tail.next = &List{cur.el, nil}
+			tl.tail = tl.tail.next
+		}
+	}
+	tl.tail.next = cell
+	tl.tail = cell
+}
@@ -0,0 +1,137 @@
+package seq
+
+import (
+	"reflect"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that a TransientSet can be built up and torn down, without changing the
+// Set it was made from
+func TestTransientSet(t *T) {
+	orig := NewSet(0, 1, 2)
+	ts := orig.Transient()
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, i > 2, ts.SetVal(i))
+	}
+	assert.Equal(t, uint64(1000), ts.Size())
+	for i := 0; i < 1000; i += 2 {
+		assert.True(t, ts.DelVal(i))
+	}
+	assert.False(t, ts.DelVal(0))
+	_, ok := ts.GetVal(1)
+	assert.True(t, ok)
+	_, ok = ts.GetVal(2)
+	assert.False(t, ok)
+
+	s := ts.Persistent()
+	assertSaneSet(t, s)
+	assert.Equal(t, uint64(500), s.Size())
+	expected := NewSet()
+	for i := 1; i < 1000; i += 2 {
+		expected, _ = expected.SetVal(i)
+	}
+	assert.True(t, expected.Equal(s))
+	assertSeqContentsSet(t, []interface{}{0, 1, 2}, orig)
+
+	// A new transient made from the result doesn't change it either
+	ts = s.Transient()
+	ts.SetVal(0)
+	ts.DelVal(1)
+	assert.True(t, expected.Equal(s))
+	assertSeqContentsSet(t, []interface{}{0, 1, 2}, orig)
+
+	// Emptying a TransientSet gives a nil Set
+	ts = NewSet(1).Transient()
+	ts.DelVal(1)
+	assert.Nil(t, ts.Persistent())
+
+	_, err := (*Set)(nil).Transient().TrySetVal([]int{})
+	assert.Equal(t, ErrUnhashable{reflect.TypeOf([]int{})}, err)
+}
+
+// Test that transients can't be used once Persistent has been called
+func TestTransientFrozen(t *T) {
+	ts := NewSet(1).Transient()
+	ts.Persistent()
+	assert.Panics(t, func() { ts.SetVal(2) })
+	assert.Panics(t, func() { ts.DelVal(1) })
+	assert.Panics(t, func() { ts.GetVal(1) })
+	assert.Panics(t, func() { ts.Size() })
+	assert.Panics(t, func() { ts.Persistent() })
+
+	thm := NewHashMap().Transient()
+	thm.Persistent()
+	assert.Panics(t, func() { thm.Set(1, 1) })
+	assert.Panics(t, func() { thm.Get(1) })
+	assert.Panics(t, func() { thm.Persistent() })
+
+	tl := NewList(1).Transient()
+	tl.Persistent()
+	assert.Panics(t, func() { tl.Append(2) })
+	assert.Panics(t, func() { tl.Prepend(0) })
+	assert.Panics(t, func() { tl.Persistent() })
+}
+
+// Test that a TransientHashMap can be built up without changing the HashMap it
+// was made from
+func TestTransientHashMap(t *T) {
+	orig := NewHashMap(KeyVal("a", 1))
+	thm := orig.Transient()
+	assert.False(t, thm.Set("a", 2))
+	assert.True(t, thm.Set("b", 3))
+	assert.True(t, thm.Set("c", 4))
+	assert.True(t, thm.Del("c"))
+	assert.False(t, thm.Del("d"))
+	v, ok := thm.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	assert.Equal(t, uint64(2), thm.Size())
+
+	m := thm.Persistent()
+	assertSeqContentsHashMap(t, []*KV{KeyVal("a", 2), KeyVal("b", 3)}, m)
+	assertSeqContentsHashMap(t, []*KV{KeyVal("a", 1)}, orig)
+
+	var nilMap *HashMap
+	thm = nilMap.Transient()
+	thm.Set(1, 1)
+	assertSeqContentsHashMap(t, []*KV{KeyVal(1, 1)}, thm.Persistent())
+}
+
+// Test that a TransientList can be appended and prepended to without changing
+// the List it was made from
+func TestTransientList(t *T) {
+	orig := NewList(1, 2, 3)
+	tl := orig.Transient()
+	tl.Append(4)
+	tl.Append(5)
+	tl.Prepend(0)
+	assert.Equal(t, NewList(0, 1, 2, 3, 4, 5), tl.Persistent())
+	assert.Equal(t, NewList(1, 2, 3), orig)
+
+	var l *List
+	tl = l.Transient()
+	tl.Prepend(2)
+	tl.Append(3)
+	tl.Prepend(1)
+	l = tl.Persistent()
+	assert.Equal(t, NewList(1, 2, 3), l)
+
+	// Appending to a List made by a frozen TransientList doesn't change it
+	tl = l.Transient()
+	tl.Append(4)
+	assert.Equal(t, NewList(1, 2, 3, 4), tl.Persistent())
+	assert.Equal(t, NewList(1, 2, 3), l)
+}
+
+func BenchmarkTransientSetSetVal(b *B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		ts := (*Set)(nil).Transient()
+		for i := 0; i < 1000; i++ {
+			ts.SetVal(i)
+		}
+		ts.Persistent()
+	}
+}
@@ -0,0 +1,58 @@
+package typed
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mediocregopher/seq"
+)
+
+// untypedSeq wraps a Seq so that it implements seq.Seq
+type untypedSeq[T any] struct {
+	s Seq[T]
+}
+
+func (u untypedSeq[T]) FirstRest() (interface{}, seq.Seq, bool) {
+	el, rest, ok := u.s.FirstRest()
+	if !ok {
+		return nil, u, false
+	}
+	return el, untypedSeq[T]{rest}, true
+}
+
+// Untyped returns a seq.Seq which yields the same elements as the given Seq,
+// so that it can be used with the functions in the seq package. Elements are
+// converted as they are iterated over, so this completes in O(1) time.
+func Untyped[T any](s Seq[T]) seq.Seq {
+	return untypedSeq[T]{s}
+}
+
+// typedSeq wraps a seq.Seq so that it implements Seq
+type typedSeq[T any] struct {
+	s seq.Seq
+}
+
+func (t typedSeq[T]) FirstRest() (T, Seq[T], bool) {
+	el, rest, ok := t.s.FirstRest()
+	if !ok {
+		var zero T
+		return zero, t, false
+	} else if el == nil {
+		var zero T
+		return zero, typedSeq[T]{rest}, true
+	}
+	elt, ok := el.(T)
+	if !ok {
+		typ := reflect.TypeOf((*T)(nil)).Elem()
+		panic(fmt.Sprintf("seq element %#v is not a %s", el, typ))
+	}
+	return elt, typedSeq[T]{rest}, true
+}
+
+// Typed returns a Seq which yields the same elements as the given seq.Seq.
+// Elements are converted as they are iterated over, so this completes in O(1)
+// time. Iterating over the returned Seq will panic if an element isn't a T; nil
+// elements are returned as the zero value of T.
+func Typed[T any](s seq.Seq) Seq[T] {
+	return typedSeq[T]{s}
+}
@@ -0,0 +1,27 @@
+package typed
+
+import (
+	. "testing"
+
+	"github.com/mediocregopher/seq"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test moving Seqs between the typed and untyped APIs
+func TestTypedUntyped(t *T) {
+	l := NewList(1, 2, 3)
+	ul := Untyped[int](l)
+	assert.Equal(t, []interface{}{1, 2, 3}, seq.ToSlice(ul))
+	assert.Equal(t, []interface{}{2, 3}, seq.ToSlice(seq.Drop(1, ul)))
+
+	tl := Typed[int](seq.NewList(1, 2, 3))
+	assert.Equal(t, []int{1, 2, 3}, ToSlice(tl))
+
+	// nil elements come back as the zero value
+	tl = Typed[int](seq.NewList(1, nil, 3))
+	assert.Equal(t, []int{1, 0, 3}, ToSlice(tl))
+
+	// elements of the wrong type cause a panic
+	tl = Typed[int](seq.NewList("a"))
+	assert.Panics(t, func() { ToSlice(tl) })
+}
@@ -0,0 +1,131 @@
+package typed
+
+import (
+	"fmt"
+
+	"github.com/mediocregopher/seq"
+)
+
+// KV is the generic form of seq.KV, a container for a key/value pair, used by
+// HashMap to hold its data
+type KV[K, V any] struct {
+	Key K
+	Val V
+}
+
+// KeyVal returns a new KV
+func KeyVal[K, V any](key K, val V) *KV[K, V] {
+	return &KV[K, V]{key, val}
+}
+
+// String is an implementation of String for Stringer
+func (kv *KV[K, V]) String() string {
+	return fmt.Sprintf("%v -> %v", kv.Key, kv.Val)
+}
+
+// HashMap is the generic form of seq.HashMap. Like Set it is a thin wrapper
+// around its untyped form, and so shares all of its characteristics. A nil
+// *HashMap is an empty HashMap.
+type HashMap[K, V any] struct {
+	hm *seq.HashMap
+}
+
+// NewHashMap returns a new HashMap of the given KVs (or possibly just an empty
+// HashMap)
+func NewHashMap[K, V any](kvs ...*KV[K, V]) *HashMap[K, V] {
+	ukvs := make([]*seq.KV, len(kvs))
+	for i := range kvs {
+		ukvs[i] = seq.KeyVal(kvs[i].Key, kvs[i].Val)
+	}
+	return &HashMap[K, V]{seq.NewHashMap(ukvs...)}
+}
+
+// Untyped returns the seq.HashMap which underlies this HashMap. Completes in
+// O(1) time.
+func (hm *HashMap[K, V]) Untyped() *seq.HashMap {
+	if hm == nil {
+		return nil
+	}
+	return hm.hm
+}
+
+// Equal implements the Equal method for the seq.Comparable and seq.Setable
+// interfaces. Other HashMaps, of any type, are compared using seq.HashMap's
+// Equal.
+func (hm *HashMap[K, V]) Equal(v interface{}) bool {
+	if hm2, ok := v.(interface{ Untyped() *seq.HashMap }); ok {
+		v = hm2.Untyped()
+	}
+	return hm.Untyped().Equal(v)
+}
+
+// Hash implements the Hash method for the seq.Setable interface
+func (hm *HashMap[K, V]) Hash(i uint32) uint32 {
+	return hm.Untyped().Hash(i)
+}
+
+func typedKV[K, V any](kv *seq.KV) *KV[K, V] {
+	k, _ := kv.Key.(K)
+	v, _ := kv.Val.(V)
+	return &KV[K, V]{k, v}
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. Completes in
+// O(log(N)) time.
+func (hm *HashMap[K, V]) FirstRest() (*KV[K, V], Seq[*KV[K, V]], bool) {
+	kv, rest, ok := hm.FirstRestKV()
+	if !ok {
+		return nil, hm, false
+	}
+	return kv, rest, true
+}
+
+// FirstRestKV is the same as FirstRest, but returns the rest already as a
+// HashMap, which may be convenient in some cases.
+func (hm *HashMap[K, V]) FirstRestKV() (*KV[K, V], *HashMap[K, V], bool) {
+	if hm.Size() == 0 {
+		return nil, nil, false
+	}
+	kv, rest, _ := hm.hm.FirstRestKV()
+	return typedKV[K, V](kv), &HashMap[K, V]{rest}, true
+}
+
+// Set returns a new HashMap with the given value set on the given key. Also
+// returns whether or not this was the first time setting that key (false if it
+// was already there and was overwritten). Has the same complexity as Set's
+// SetVal method.
+func (hm *HashMap[K, V]) Set(key K, val V) (*HashMap[K, V], bool) {
+	nhm, ok := hm.Untyped().Set(key, val)
+	return &HashMap[K, V]{nhm}, ok
+}
+
+// Del returns a new HashMap with the given key removed from it. Also returns
+// whether or not the key was already there (true if so, false if not). Has the
+// same time complexity as Set's DelVal method.
+func (hm *HashMap[K, V]) Del(key K) (*HashMap[K, V], bool) {
+	nhm, ok := hm.Untyped().Del(key)
+	return &HashMap[K, V]{nhm}, ok
+}
+
+// Get returns a value for a given key from the HashMap, along with a boolean
+// indicating whether or not the value was found. Has the same time complexity
+// as Set's GetVal method.
+func (hm *HashMap[K, V]) Get(key K) (V, bool) {
+	v, ok := hm.Untyped().Get(key)
+	vt, _ := v.(V)
+	return vt, ok
+}
+
+// String is an implementation of String for Stringer interface
+func (hm *HashMap[K, V]) String() string {
+	return ToString[*KV[K, V]](hm, "{", "}")
+}
+
+// Size returns the number of KVs in the HashMap. Has the same complexity as
+// Set's Size method.
+func (hm *HashMap[K, V]) Size() uint64 {
+	if hm == nil {
+		return 0
+	}
+	return hm.hm.Size()
+}
@@ -0,0 +1,63 @@
+package typed
+
+import (
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test creating a HashMap and calling the Seq interface methods on it
+func TestHashMapSeq(t *T) {
+	m := NewHashMap(KeyVal("one", 1), KeyVal("two", 2))
+	assert.Equal(t, uint64(2), m.Size())
+	assert.Equal(t, uint64(2), Size[*KV[string, int]](m))
+
+	got := map[string]int{}
+	var s Seq[*KV[string, int]] = m
+	for {
+		kv, rest, ok := s.FirstRest()
+		if !ok {
+			break
+		}
+		got[kv.Key] = kv.Val
+		s = rest
+	}
+	assert.Equal(t, map[string]int{"one": 1, "two": 2}, got)
+}
+
+// Test setting, getting and deleting keys on a HashMap
+func TestHashMapSetGetDel(t *T) {
+	var m *HashMap[string, int]
+	v, ok := m.Get("one")
+	assert.Equal(t, 0, v)
+	assert.Equal(t, false, ok)
+
+	m1, ok := m.Set("one", 1)
+	assert.Equal(t, true, ok)
+	m2, ok := m1.Set("one", 11)
+	assert.Equal(t, false, ok)
+
+	v, ok = m1.Get("one")
+	assert.Equal(t, 1, v)
+	assert.Equal(t, true, ok)
+	v, _ = m2.Get("one")
+	assert.Equal(t, 11, v)
+
+	m3, ok := m2.Del("one")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, uint64(0), m3.Size())
+	_, ok = m3.Del("one")
+	assert.Equal(t, false, ok)
+	assert.Equal(t, uint64(0), m.Size())
+}
+
+// Test that two HashMaps compare equality correctly
+func TestHashMapEqual(t *T) {
+	m1 := NewHashMap(KeyVal("one", 1), KeyVal("two", 2))
+	m2 := NewHashMap(KeyVal("two", 2), KeyVal("one", 1))
+	assert.Equal(t, true, m1.Equal(m2))
+	assert.Equal(t, true, m1.Equal(m2.Untyped()))
+
+	m2, _ = m2.Set("two", 3)
+	assert.Equal(t, false, m1.Equal(m2))
+}
@@ -0,0 +1,141 @@
+package typed
+
+import (
+	"github.com/mediocregopher/seq"
+)
+
+// Set is the generic form of seq.Set, a persistent hash-tree. It is a thin
+// wrapper around a seq.Set, so it has exactly the same structure sharing and
+// performance characteristics, and the same requirements on its elements (they
+// must be hashable by seq, see seq.Setable). A nil *Set is an empty Set.
+type Set[T any] struct {
+	set *seq.Set
+}
+
+func wrapSet[T any](set *seq.Set) *Set[T] {
+	if set == nil {
+		return nil
+	}
+	return &Set[T]{set}
+}
+
+// NewSet returns a new Set of the given elements (or no elements, for an empty
+// set)
+func NewSet[T any](vals ...T) *Set[T] {
+	ints := make([]interface{}, len(vals))
+	for i := range vals {
+		ints[i] = vals[i]
+	}
+	return wrapSet[T](seq.NewSet(ints...))
+}
+
+// Untyped returns the seq.Set which underlies this Set. Completes in O(1)
+// time.
+func (set *Set[T]) Untyped() *seq.Set {
+	if set == nil {
+		return nil
+	}
+	return set.set
+}
+
+// Equal implements the Equal method for the seq.Comparable and seq.Setable
+// interfaces. Other Sets, of any type, are compared using seq.Set's Equal.
+func (set *Set[T]) Equal(v interface{}) bool {
+	if set2, ok := v.(interface{ Untyped() *seq.Set }); ok {
+		v = set2.Untyped()
+	}
+	return set.Untyped().Equal(v)
+}
+
+// Hash implements the Hash method for the seq.Setable interface
+func (set *Set[T]) Hash(i uint32) uint32 {
+	return set.Untyped().Hash(i)
+}
+
+// SetVal returns a new Set with the given value added to it. Also returns
+// whether or not this is the first time setting this value (false if it was
+// already there and was overwritten). Completes in O(log(N)) time.
+func (set *Set[T]) SetVal(val T) (*Set[T], bool) {
+	nset, ok := set.Untyped().SetVal(val)
+	return wrapSet[T](nset), ok
+}
+
+// DelVal returns a new Set with the given value removed from it and whether or
+// not the value was actually removed. Completes in O(log(N)) time.
+func (set *Set[T]) DelVal(val T) (*Set[T], bool) {
+	nset, ok := set.Untyped().DelVal(val)
+	return wrapSet[T](nset), ok
+}
+
+// GetVal returns a value from the Set, along with a boolean indicating whether
+// or not the value was found. Completes in O(log(N)) time.
+func (set *Set[T]) GetVal(val T) (T, bool) {
+	v, ok := set.Untyped().GetVal(val)
+	vt, _ := v.(T)
+	return vt, ok
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. Completes in
+// O(log(N)) time.
+func (set *Set[T]) FirstRest() (T, Seq[T], bool) {
+	el, rest, ok := set.Untyped().FirstRest()
+	if !ok {
+		var zero T
+		return zero, set, false
+	}
+	elt, _ := el.(T)
+	return elt, wrapSet[T](rest.(*seq.Set)), true
+}
+
+// String is an implementation of String for Stringer interface
+func (set *Set[T]) String() string {
+	return set.Untyped().String()
+}
+
+// Size returns the number of elements in the Set. Completes in O(1) time.
+func (set *Set[T]) Size() uint64 {
+	return set.Untyped().Size()
+}
+
+// Union returns a Set with all of the elements of the original Set along with
+// everything in the given Seq. See seq.Set's Union.
+func (set *Set[T]) Union(s Seq[T]) *Set[T] {
+	return wrapSet[T](set.Untyped().Union(untypedSet(s)))
+}
+
+// Intersection returns a Set with all of the elements in Seq that are also in
+// Set. See seq.Set's Intersection.
+func (set *Set[T]) Intersection(s Seq[T]) *Set[T] {
+	return wrapSet[T](set.Untyped().Intersection(untypedSet(s)))
+}
+
+// Difference returns a Set of all elements in the original Set that aren't in
+// the Seq. See seq.Set's Difference.
+func (set *Set[T]) Difference(s Seq[T]) *Set[T] {
+	return wrapSet[T](set.Untyped().Difference(untypedSet(s)))
+}
+
+// SymDifference returns a Set of all elements that are either in the original
+// Set or the given Seq, but not in both. See seq.Set's SymDifference.
+func (set *Set[T]) SymDifference(s Seq[T]) *Set[T] {
+	return wrapSet[T](set.Untyped().SymDifference(untypedSet(s)))
+}
+
+// untypedSet is like Untyped, but passes Sets through as their underlying
+// seq.Set so the seq.Set methods can recognize them
+func untypedSet[T any](s Seq[T]) seq.Seq {
+	if set, ok := s.(*Set[T]); ok {
+		return set.Untyped()
+	}
+	return Untyped(s)
+}
+
+// ToSet returns the elements in the Seq as a Set. In general this completes in
+// O(N*log(N)) time. If the given Seq is already a Set it will complete in O(1)
+// time.
+func ToSet[T any](s Seq[T]) *Set[T] {
+	if set, ok := s.(*Set[T]); ok {
+		return set
+	}
+	return NewSet(ToSlice(s)...)
+}
@@ -0,0 +1,87 @@
+package typed
+
+import (
+	"sort"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sortedInts(s Seq[int]) []int {
+	ints := ToSlice(s)
+	sort.Ints(ints)
+	return ints
+}
+
+// Test creating a Set and calling the Seq interface methods on it
+func TestSetSeq(t *T) {
+	s := NewSet(3, 1, 2)
+	assert.Equal(t, uint64(3), s.Size())
+	assert.Equal(t, uint64(3), Size[int](s))
+	assert.Equal(t, []int{1, 2, 3}, sortedInts(s))
+
+	var rest Seq[int] = s
+	var el int
+	var ok bool
+	m := map[int]bool{}
+	for {
+		if el, rest, ok = rest.FirstRest(); !ok {
+			break
+		}
+		m[el] = true
+	}
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true}, m)
+
+	var nilpointer *Set[int]
+	assert.Equal(t, nilpointer, NewSet[int]())
+	assert.Equal(t, uint64(0), NewSet[int]().Size())
+}
+
+// Test setting, getting and deleting values on a Set
+func TestSetSetGetDel(t *T) {
+	s := NewSet[string]()
+	s1, ok := s.SetVal("a")
+	assert.Equal(t, true, ok)
+	s1, ok = s1.SetVal("a")
+	assert.Equal(t, false, ok)
+
+	v, ok := s1.GetVal("a")
+	assert.Equal(t, "a", v)
+	assert.Equal(t, true, ok)
+	v, ok = s.GetVal("a")
+	assert.Equal(t, "", v)
+	assert.Equal(t, false, ok)
+
+	s2, ok := s1.DelVal("a")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, uint64(0), s2.Size())
+	assert.Equal(t, uint64(1), s1.Size())
+}
+
+// Test the set operations on a Set, both with Set and non-Set arguments
+func TestSetOps(t *T) {
+	s1 := NewSet(0, 1, 2, 3)
+	s2 := NewSet(2, 3, 4)
+	l2 := NewList(2, 3, 4)
+
+	for _, s := range []Seq[int]{s2, l2} {
+		assert.Equal(t, []int{0, 1, 2, 3, 4}, sortedInts(s1.Union(s)))
+		assert.Equal(t, []int{2, 3}, sortedInts(s1.Intersection(s)))
+		assert.Equal(t, []int{0, 1}, sortedInts(s1.Difference(s)))
+		assert.Equal(t, []int{0, 1, 4}, sortedInts(s1.SymDifference(s)))
+	}
+	assert.Equal(t, []int{0, 1, 2, 3}, sortedInts(s1))
+}
+
+// Test that two sets compare equality correctly, including against untyped
+// Sets
+func TestSetEqual(t *T) {
+	s1, s2 := NewSet(1, 2), NewSet(2, 1)
+	assert.Equal(t, true, s1.Equal(s2))
+	assert.Equal(t, true, s1.Equal(s2.Untyped()))
+	assert.Equal(t, true, s2.Untyped().Equal(s1.Untyped()))
+
+	s2, _ = s2.SetVal(3)
+	assert.Equal(t, false, s1.Equal(s2))
+	assert.Equal(t, true, NewSet[int]().Equal(NewSet[int]()))
+}
@@ -0,0 +1,35 @@
+package typed
+
+import (
+	"iter"
+
+	"github.com/mediocregopher/seq"
+)
+
+// Values returns an iterator over the elements of the given Seq, for use with
+// range. The elements are yielded in the same order FirstRest would return
+// them. Each call to the returned iterator starts from the beginning of the
+// Seq.
+func Values[T any](s Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		var el T
+		var ok bool
+		for cur := s; ; {
+			if el, cur, ok = cur.FirstRest(); !ok || !yield(el) {
+				return
+			}
+		}
+	}
+}
+
+// FromIter returns a Lazy which yields the values of the given iterator. See
+// seq.FromIter.
+func FromIter[T any](it iter.Seq[T]) *Lazy[T] {
+	return wrapLazy[T](seq.FromIter(func(yield func(interface{}) bool) {
+		for el := range it {
+			if !yield(el) {
+				return
+			}
+		}
+	}))
+}
@@ -0,0 +1,116 @@
+package typed
+
+import (
+	"github.com/mediocregopher/seq"
+)
+
+// Lazy is the generic form of seq.Lazy. It is a thin wrapper around a seq.Lazy,
+// so it only evaluates its contents as those contents become needed, caches the
+// results, and is thread-safe, so multiple routines can interact with the same
+// Lazy at the same time but the contents will only be evaluated once. Like
+// seq.Lazy, each element is evaluated on the go-routine which first asks for
+// it.
+type Lazy[T any] struct {
+	l *seq.Lazy
+}
+
+func wrapLazy[T any](l *seq.Lazy) *Lazy[T] {
+	if l == nil {
+		return nil
+	}
+	return &Lazy[T]{l}
+}
+
+// Thunk is the building block of a Lazy. A Thunk returns an element, another
+// Thunk, and a boolean representing if the call yielded any results or if it
+// was actually empty (true indicates it yielded results).
+type Thunk[T any] func() (T, Thunk[T], bool)
+
+// Returns a seq.Thunk which yields the same elements as the given Thunk
+func untypedThunk[T any](t Thunk[T]) seq.Thunk {
+	return func() (interface{}, seq.Thunk, bool) {
+		el, next, ok := t()
+		if !ok {
+			return nil, nil, false
+		}
+		return el, untypedThunk(next), true
+	}
+}
+
+// NewLazy returns a Lazy around the given Thunk
+func NewLazy[T any](t Thunk[T]) *Lazy[T] {
+	return wrapLazy[T](seq.NewLazy(untypedThunk(t)))
+}
+
+// Untyped returns the seq.Lazy which underlies this Lazy. Completes in O(1)
+// time.
+func (l *Lazy[T]) Untyped() *seq.Lazy {
+	if l == nil {
+		return nil
+	}
+	return l.l
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. The first call
+// to FirstRest evaluates the Lazy's Thunk, all subsequent calls return the
+// cached result in O(1) time.
+func (l *Lazy[T]) FirstRest() (T, Seq[T], bool) {
+	el, rest, ok := l.Untyped().FirstRest()
+	if !ok {
+		var zero T
+		return zero, l, false
+	}
+	elt, _ := el.(T)
+	return elt, wrapLazy[T](rest.(*seq.Lazy)), true
+}
+
+// Err returns the error the Lazy ended with, if any. See seq.Lazy's Err.
+func (l *Lazy[T]) Err() error {
+	return l.Untyped().Err()
+}
+
+// String is an implementation of String for Stringer
+func (l *Lazy[T]) String() string {
+	return l.Untyped().String()
+}
+
+// untypedLazy is like Untyped, but passes Lazys through as their underlying
+// seq.Lazy so the seq functions can see any error they end with
+func untypedLazy[T any](s Seq[T]) seq.Seq {
+	if l, ok := s.(*Lazy[T]); ok {
+		return l.Untyped()
+	}
+	return Untyped(s)
+}
+
+// Wraps the Seq returned by one of seq's lazy functions, which is always a
+// *seq.Lazy
+func lazySeq[T any](s seq.Seq) *Lazy[T] {
+	return wrapLazy[T](s.(*seq.Lazy))
+}
+
+// LMap is a lazy implementation of Map. See seq.LMap.
+func LMap[T, U any](fn func(T) U, s Seq[T]) Seq[U] {
+	return lazySeq[U](seq.LMap(func(el interface{}) interface{} {
+		elt, _ := el.(T)
+		return fn(elt)
+	}, untypedLazy(s)))
+}
+
+// LFilter is a lazy implementation of Filter. See seq.LFilter.
+func LFilter[T any](fn func(T) bool, s Seq[T]) Seq[T] {
+	return lazySeq[T](seq.LFilter(func(el interface{}) bool {
+		elt, _ := el.(T)
+		return fn(elt)
+	}, untypedLazy(s)))
+}
+
+// ToLazy returns the Seq as a Lazy. Pointless for linked-lists, but possibly
+// useful for other implementations where FirstRest might be costly and the same
+// Seq needs to be iterated over many times.
+func ToLazy[T any](s Seq[T]) *Lazy[T] {
+	if l, ok := s.(*Lazy[T]); ok {
+		return l
+	}
+	return wrapLazy[T](seq.ToLazy(Untyped(s)))
+}
@@ -0,0 +1,80 @@
+package typed
+
+import (
+	"errors"
+	"runtime"
+	. "testing"
+	"time"
+
+	"github.com/mediocregopher/seq"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test lazy operation and thread-safety
+func TestLazyBasic(t *T) {
+	ch := make(chan int)
+	mapfn := func(i int) int {
+		ch <- i
+		return i
+	}
+
+	intl := []int{0, 1, 2, 3, 4}
+	ml := LMap(mapfn, Seq[int](NewList(intl...)))
+
+	for i := 0; i < 10; i++ {
+		go func() {
+			assert.Equal(t, intl, ToSlice(ml))
+		}()
+	}
+
+	for _, el := range intl {
+		select {
+		case elch := <-ch:
+			assert.Equal(t, elch, el)
+		case <-time.After(1 * time.Millisecond):
+			t.Fatalf("Took too long reading result")
+		}
+	}
+	close(ch)
+}
+
+// Test that arbitrary Seqs can turn into Lazy
+func TestToLazy(t *T) {
+	ll := ToLazy[int](NewSet(0))
+	assert.Equal(t, []int{0}, ToSlice[int](ll))
+	assert.Equal(t, ll, ToLazy[int](ll))
+}
+
+// Test that partially reading from a Lazy doesn't leave any go-routines behind
+func TestLazyNoGoroutines(t *T) {
+	var numbers func(int) Thunk[int]
+	numbers = func(i int) Thunk[int] {
+		return func() (int, Thunk[int], bool) {
+			return i, numbers(i + 1), true
+		}
+	}
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		l := LMap(func(i int) int { return i * 2 }, Seq[int](NewLazy(numbers(0))))
+		assert.Equal(t, []int{0, 2, 4}, ToSlice(Take(3, l)))
+	}
+	assert.Equal(t, before, runtime.NumGoroutine())
+}
+
+// Test that an error ending the underlying seq.Lazy is passed through the lazy
+// functions
+func TestLazyErr(t *T) {
+	errBoom := errors.New("boom")
+	l := wrapLazy[int](seq.NewLazyErr(func() (interface{}, seq.ErrThunk, bool, error) {
+		return 1, func() (interface{}, seq.ErrThunk, bool, error) {
+			return nil, nil, false, errBoom
+		}, true, nil
+	}))
+
+	ml := LMap(func(i int) int { return i * 2 }, Seq[int](l))
+	assert.Equal(t, []int{2}, ToSlice(ml))
+	_, rest, _ := ml.FirstRest()
+	assert.Equal(t, errBoom, rest.(*Lazy[int]).Err())
+	assert.Nil(t, NewLazy(func() (int, Thunk[int], bool) { return 0, nil, false }).Err())
+}
@@ -0,0 +1,115 @@
+package typed
+
+// List is the generic form of seq.List, a single-linked-list. As with seq.List,
+// a nil *List is an empty List, and operations which add to the front of a List
+// share all of the original List's nodes with the new one.
+type List[T any] struct {
+	el   T
+	next *List[T]
+}
+
+// NewList returns a new List comprised of the given elements (or no elements,
+// for an empty list)
+func NewList[T any](els ...T) *List[T] {
+	var cur *List[T]
+	for i := len(els) - 1; i >= 0; i-- {
+		cur = &List[T]{els[i], cur}
+	}
+	return cur
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. Completes in
+// O(1) time.
+func (l *List[T]) FirstRest() (T, Seq[T], bool) {
+	if l == nil {
+		var zero T
+		return zero, l, false
+	}
+	return l.el, l.next, true
+}
+
+// String is an implementation of String for Stringer interface.
+func (l *List[T]) String() string {
+	return ToString[T](l, "(", ")")
+}
+
+// Prepend prepends the given element to the front of the list, returning a
+// copy of the new list. Completes in O(1) time.
+func (l *List[T]) Prepend(el T) *List[T] {
+	return &List[T]{el, l}
+}
+
+// PrependSeq prepends the argument Seq to the beginning of the callee List,
+// returning a copy of the new List. Completes in O(N) time, N being the length
+// of the argument Seq
+func (l *List[T]) PrependSeq(s Seq[T]) *List[T] {
+	var first, cur, prev *List[T]
+	var el T
+	var ok bool
+	for {
+		if el, s, ok = s.FirstRest(); !ok {
+			break
+		}
+		cur = &List[T]{el, nil}
+		if first == nil {
+			first = cur
+		}
+		if prev != nil {
+			prev.next = cur
+		}
+		prev = cur
+	}
+
+	// prev will be nil if s is empty
+	if prev == nil {
+		return l
+	}
+
+	prev.next = l
+	return first
+}
+
+// Append appends the given element to the end of the List, returning a copy of
+// the new List. While most methods on List don't actually copy much data, this
+// one copies the entire list. Completes in O(N) time.
+func (l *List[T]) Append(el T) *List[T] {
+	var first, cur, prev *List[T]
+	for ; l != nil; l = l.next {
+		cur = &List[T]{l.el, nil}
+		if first == nil {
+			first = cur
+		}
+		if prev != nil {
+			prev.next = cur
+		}
+		prev = cur
+	}
+	final := &List[T]{el, nil}
+	if prev == nil {
+		return final
+	}
+	prev.next = final
+	return first
+}
+
+// Nth returns the nth index element (starting at 0), with bool being false if i
+// is out of bounds. Completes in O(N) time.
+func (l *List[T]) Nth(n uint64) (T, bool) {
+	for i := uint64(0); l != nil; i, l = i+1, l.next {
+		if i == n {
+			return l.el, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// ToList returns the elements in the Seq as a List. Has similar properties as
+// ToSlice. In general this completes in O(N) time. If the given Seq is already
+// a List it will complete in O(1) time.
+func ToList[T any](s Seq[T]) *List[T] {
+	if l, ok := s.(*List[T]); ok {
+		return l
+	}
+	return NewList[T]().PrependSeq(s)
+}
@@ -0,0 +1,55 @@
+package typed
+
+import (
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test creating a list and calling the Seq interface methods on it
+func TestListSeq(t *T) {
+	l := NewList("a", "b", "c")
+	assert.Equal(t, uint64(3), Size[string](l))
+	assert.Equal(t, []string{"a", "b", "c"}, ToSlice[string](l))
+
+	first, rest, ok := l.FirstRest()
+	assert.Equal(t, "a", first)
+	assert.Equal(t, []string{"b", "c"}, ToSlice(rest))
+	assert.Equal(t, true, ok)
+
+	var nilpointer *List[string]
+	assert.Equal(t, nilpointer, NewList[string]())
+	first, _, ok = NewList[string]().FirstRest()
+	assert.Equal(t, "", first)
+	assert.Equal(t, false, ok)
+}
+
+// Test the string representation of a List
+func TestListString(t *T) {
+	assert.Equal(t, "( 0 1 2 3 )", NewList(0, 1, 2, 3).String())
+}
+
+// Test adding elements to the front and back of a List
+func TestListPrependAppend(t *T) {
+	l := NewList(1, 2)
+	assert.Equal(t, []int{0, 1, 2}, ToSlice[int](l.Prepend(0)))
+	assert.Equal(t, []int{1, 2, 3}, ToSlice[int](l.Append(3)))
+	assert.Equal(t, []int{-1, 0, 1, 2}, ToSlice[int](l.PrependSeq(NewList(-1, 0))))
+	assert.Equal(t, []int{1, 2}, ToSlice[int](l))
+
+	var empty *List[int]
+	assert.Equal(t, []int{0}, ToSlice[int](empty.Append(0)))
+	assert.Equal(t, []int{1, 2}, ToSlice[int](l.PrependSeq(empty)))
+}
+
+// Test retrieving items from a List
+func TestListNth(t *T) {
+	l := NewList(0, 2, 4, 6, 8)
+	r, ok := l.Nth(3)
+	assert.Equal(t, 6, r)
+	assert.Equal(t, true, ok)
+
+	r, ok = l.Nth(8)
+	assert.Equal(t, 0, r)
+	assert.Equal(t, false, ok)
+}
@@ -0,0 +1,190 @@
+// Package typed provides generic, type-safe versions of seq's data structures
+// and functions. The structures share their designs (and in the case of Set and
+// HashMap their actual implementation) with the untyped versions in the parent
+// package, so they have the same immutability, structure sharing and
+// thread-safety properties, but elements don't need to be type-asserted when
+// they come back out.
+//
+// The untyped API in the parent package remains fully supported. The Typed and
+// Untyped functions can be used to move between the two.
+package typed
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Seq is the generic form of seq.Seq. It is the general interface which most
+// operations in this package will actually operate on.
+type Seq[T any] interface {
+
+	// FirstRest returns the "first" element in the data structure as well as a
+	// Seq containing a copy of the rest of the elements in the data structure.
+	// The "first" element can be random for structures which don't have a
+	// concept of order (like Set). Calling FirstRest on an empty Seq will
+	// return "first" as the zero value of T, an empty Seq, and false. The third
+	// return value is true in all other cases.
+	FirstRest() (T, Seq[T], bool)
+}
+
+// sizer is implemented by the structures which know their own size, so Size
+// doesn't need to iterate over them
+type sizer interface {
+	Size() uint64
+}
+
+// Size returns the number of elements contained in the data structure. In
+// general this completes in O(N) time, except for Set and HashMap for which it
+// completes in O(1)
+func Size[T any](s Seq[T]) uint64 {
+	if st, ok := s.(sizer); ok {
+		return st.Size()
+	}
+
+	var ok bool
+	for i := uint64(0); ; {
+		if _, s, ok = s.FirstRest(); ok {
+			i++
+		} else {
+			return i
+		}
+	}
+}
+
+// ToSlice returns the elements in the Seq as a slice. If the underlying Seq has
+// any implicit order to it that order will be kept. An empty Seq will return an
+// empty slice; nil is never returned. In general this completes in O(N) time.
+func ToSlice[T any](s Seq[T]) []T {
+	var el T
+	var ok bool
+	for ret := make([]T, 0, 8); ; {
+		if el, s, ok = s.FirstRest(); ok {
+			ret = append(ret, el)
+		} else {
+			return ret
+		}
+	}
+}
+
+// ToString turns a Seq into a string, with each element separated by a space
+// and with a dstart and dend wrapping the whole thing
+func ToString[T any](s Seq[T], dstart, dend string) string {
+	buf := bytes.NewBufferString(dstart)
+	buf.WriteString(" ")
+	var el T
+	var ok bool
+	for {
+		if el, s, ok = s.FirstRest(); !ok {
+			break
+		}
+		if strel, ok := any(el).(fmt.Stringer); ok {
+			buf.WriteString(strel.String())
+		} else {
+			buf.WriteString(fmt.Sprintf("%v", el))
+		}
+		buf.WriteString(" ")
+	}
+	buf.WriteString(dend)
+	return buf.String()
+}
+
+// Reverse returns a reversed copy of the Seq as a List. Completes in O(N) time.
+func Reverse[T any](s Seq[T]) *List[T] {
+	l := NewList[T]()
+	var el T
+	var ok bool
+	for {
+		if el, s, ok = s.FirstRest(); ok {
+			l = l.Prepend(el)
+		} else {
+			return l
+		}
+	}
+}
+
+// Map returns a Seq consisting of the result of applying fn to each element in
+// the given Seq. Completes in O(N) time.
+func Map[T, U any](fn func(T) U, s Seq[T]) Seq[U] {
+	l := NewList[U]()
+	var el T
+	var ok bool
+	for {
+		if el, s, ok = s.FirstRest(); ok {
+			l = l.Prepend(fn(el))
+		} else {
+			return Reverse[U](l)
+		}
+	}
+}
+
+// ReduceFn is a function used in a reduce. The first argument is the
+// accumulator, the second is an element from the Seq being reduced over. The
+// ReduceFn returns the accumulator to be used in the next iteration, wherein
+// that new accumulator will be called alongside the next element in the Seq.
+// ReduceFn also returns a boolean representing whether or not the reduction
+// should stop at this step. If true, the reductions will stop and any remaining
+// elements in the Seq will be ignored.
+type ReduceFn[A, T any] func(acc A, el T) (A, bool)
+
+// Reduce reduces over the given Seq using ReduceFn, with acc as the first
+// accumulator value in the reduce. See ReduceFn for more details on how it
+// works. The return value is the result of the reduction. Completes in O(N)
+// time.
+func Reduce[A, T any](fn ReduceFn[A, T], acc A, s Seq[T]) A {
+	var el T
+	var ok, stop bool
+	for {
+		if el, s, ok = s.FirstRest(); !ok {
+			return acc
+		} else if acc, stop = fn(acc, el); stop {
+			return acc
+		}
+	}
+}
+
+// Filter returns a Seq containing all elements in the given Seq for which fn
+// returned true. Completes in O(N) time.
+func Filter[T any](fn func(T) bool, s Seq[T]) Seq[T] {
+	l := NewList[T]()
+	var el T
+	var ok bool
+	for {
+		if el, s, ok = s.FirstRest(); ok {
+			if fn(el) {
+				l = l.Prepend(el)
+			}
+		} else {
+			return Reverse[T](l)
+		}
+	}
+}
+
+// Take returns a Seq containing the first n elements in the given Seq. If n is
+// greater than the length of the given Seq then the whole Seq is returned.
+// Completes in O(N) time.
+func Take[T any](n uint64, s Seq[T]) Seq[T] {
+	l := NewList[T]()
+	var el T
+	var ok bool
+	for i := uint64(0); i < n; i++ {
+		if el, s, ok = s.FirstRest(); !ok {
+			break
+		}
+		l = l.Prepend(el)
+	}
+	return Reverse[T](l)
+}
+
+// Drop returns a Seq which the is the previous Seq without the first n
+// elements. If n is greater than the length of the Seq, returns an empty Seq.
+// Completes in O(N) time.
+func Drop[T any](n uint64, s Seq[T]) Seq[T] {
+	for i := uint64(0); i < n; i++ {
+		_, rest, ok := s.FirstRest()
+		if !ok {
+			break
+		}
+		s = rest
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package typed
+
+import (
+	"slices"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test reversing a Seq
+func TestReverse(t *T) {
+	l := NewList(3, 2, 1)
+	assert.Equal(t, []int{1, 2, 3}, ToSlice[int](Reverse[int](l)))
+	assert.Equal(t, []int{3, 2, 1}, ToSlice[int](l))
+	assert.Equal(t, uint64(0), Size[int](Reverse[int](NewList[int]())))
+}
+
+func testMapGen(t *T, mapFn func(func(int) string, Seq[int]) Seq[string]) {
+	fn := func(i int) string {
+		return string(rune('a' + i))
+	}
+
+	// Normal case
+	l := NewList(0, 1, 2)
+	nl := mapFn(fn, l)
+	assert.Equal(t, []int{0, 1, 2}, ToSlice[int](l))
+	assert.Equal(t, []string{"a", "b", "c"}, ToSlice(nl))
+
+	// Degenerate case
+	nl = mapFn(fn, NewList[int]())
+	assert.Equal(t, uint64(0), Size(nl))
+}
+
+// Test mapping over a Seq
+func TestMap(t *T) {
+	testMapGen(t, Map[int, string])
+}
+
+// Test lazily mapping over a Seq
+func TestLMap(t *T) {
+	testMapGen(t, LMap[int, string])
+}
+
+// Test reducing over a Seq
+func TestReduce(t *T) {
+	fn := func(acc string, el int) (string, bool) {
+		return acc + string(rune('0'+el)), false
+	}
+
+	// Normal case
+	l := NewList(1, 2, 3, 4)
+	assert.Equal(t, "1234", Reduce[string, int](fn, "", l))
+
+	// Short-circuit case
+	fns := func(acc string, el int) (string, bool) {
+		return acc + string(rune('0'+el)), el > 2
+	}
+	assert.Equal(t, "123", Reduce[string, int](fns, "", l))
+
+	// Degenerate case
+	assert.Equal(t, "", Reduce[string, int](fn, "", NewList[int]()))
+}
+
+func testFilterGen(t *T, filterFn func(func(int) bool, Seq[int]) Seq[int]) {
+	fn := func(i int) bool {
+		return i%2 != 0
+	}
+
+	// Normal case
+	l := NewList(1, 2, 3, 4, 5)
+	r := filterFn(fn, l)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ToSlice[int](l))
+	assert.Equal(t, []int{1, 3, 5}, ToSlice(r))
+
+	// Degenerate case
+	r = filterFn(fn, NewList[int]())
+	assert.Equal(t, uint64(0), Size(r))
+}
+
+// Test the Filter function
+func TestFilter(t *T) {
+	testFilterGen(t, Filter[int])
+}
+
+// Test the lazy Filter function
+func TestLFilter(t *T) {
+	testFilterGen(t, LFilter[int])
+}
+
+// Test taking from a Seq
+func TestTake(t *T) {
+	l := NewList(0, 1, 2, 3, 4)
+	assert.Equal(t, []int{0, 1, 2}, ToSlice(Take[int](3, l)))
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, ToSlice(Take[int](6, l)))
+	assert.Equal(t, []int{}, ToSlice(Take[int](0, l)))
+	assert.Equal(t, []int{}, ToSlice(Take[int](1, NewList[int]())))
+}
+
+// Test dropping from a Seq
+func TestDrop(t *T) {
+	l := NewList(0, 1, 2, 3, 4)
+	assert.Equal(t, []int{3, 4}, ToSlice(Drop[int](3, l)))
+	assert.Equal(t, []int{}, ToSlice(Drop[int](6, l)))
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, ToSlice(Drop[int](0, l)))
+	assert.Equal(t, []int{}, ToSlice(Drop[int](1, NewList[int]())))
+
+	// Dropping past the end of a Lazy
+	ll := ToLazy[int](l)
+	assert.Equal(t, []int{}, ToSlice(Drop[int](6, ll)))
+}
+
+// Test ranging over a Seq, and creating a Lazy from an iterator
+func TestValuesFromIter(t *T) {
+	var got []int
+	for i := range Values[int](NewList(0, 1, 2)) {
+		got = append(got, i)
+	}
+	assert.Equal(t, []int{0, 1, 2}, got)
+
+	l := FromIter(slices.Values([]string{"a", "b"}))
+	assert.Equal(t, []string{"a", "b"}, slices.Collect(Values[string](l)))
+}
@@ -0,0 +1,336 @@
+package seq
+
+// The Vector is a persistent bit-partitioned trie, in the style of clojure's
+// PersistentVector. Each node in the trie has up to ARITY children, and the
+// last (up to) ARITY elements are kept in a separate tail slice so that most
+// appends don't need to touch the trie at all.
+
+// The number of bits of an index used at each level of the Vector trie
+const vectorBits = 5
+
+const vectorMask = ARITY - 1
+
+type vnode struct {
+	// Only one of these will be set, depending on if the node is a leaf or not
+	kids []*vnode
+	vals []interface{}
+}
+
+func (n *vnode) clone() *vnode {
+	cn := &vnode{}
+	if n == nil || n.vals == nil {
+		cn.kids = make([]*vnode, ARITY)
+		if n != nil {
+			copy(cn.kids, n.kids)
+		}
+	} else {
+		cn.vals = make([]interface{}, ARITY)
+		copy(cn.vals, n.vals)
+	}
+	return cn
+}
+
+// Vector is an implementation of Seq in the form of a persistent indexed
+// vector. Like Set it is built on a tree of ARITY-way nodes, so random access
+// and modification complete in O(log32(N)) time, and all operations share
+// nodes with the Vector they were performed on rather than copying it.
+//
+// A nil *Vector is an empty Vector, as is a zero Vector, which is what
+// UnmarshalJSON makes from an empty JSON array.
+type Vector struct {
+	root  *vnode
+	tail  []interface{}
+	shift uint
+
+	// cnt is the number of elements stored in the trie and tail together. off
+	// is the index of the first of those elements which is actually part of
+	// this Vector; everything before it has been sliced or popped off the
+	// front, but may still be shared with other Vectors.
+	cnt, off uint64
+}
+
+// NewVector returns a new Vector comprised of the given elements (or no
+// elements, for an empty vector)
+func NewVector(els ...interface{}) *Vector {
+	var v *Vector
+	for i := range els {
+		v = v.Append(els[i])
+	}
+	return v
+}
+
+// Size returns the number of elements in the Vector. Completes in O(1) time.
+func (v *Vector) Size() uint64 {
+	if v == nil {
+		return 0
+	}
+	return v.cnt - v.off
+}
+
+// Returns the index of the first element in the tail
+func (v *Vector) tailoff() uint64 {
+	return vectorTailoff(v.cnt)
+}
+
+func vectorTailoff(cnt uint64) uint64 {
+	if cnt < ARITY {
+		return 0
+	}
+	return ((cnt - 1) >> vectorBits) << vectorBits
+}
+
+// Returns the leaf slice which holds the element at the given absolute (not
+// offset) index
+func (v *Vector) leafFor(i uint64) []interface{} {
+	if i >= v.tailoff() {
+		return v.tail
+	}
+	node := v.root
+	for level := v.shift; level > 0; level -= vectorBits {
+		node = node.kids[(i>>level)&vectorMask]
+	}
+	return node.vals
+}
+
+// Nth returns the nth index element (starting at 0), with bool being false if n
+// is out of bounds. Completes in O(log32(N)) time.
+func (v *Vector) Nth(n uint64) (interface{}, bool) {
+	if n >= v.Size() {
+		return nil, false
+	}
+	i := v.off + n
+	return v.leafFor(i)[i&vectorMask], true
+}
+
+func newVectorPath(level uint, node *vnode) *vnode {
+	if level == 0 {
+		return node
+	}
+	ret := &vnode{kids: make([]*vnode, ARITY)}
+	ret.kids[0] = newVectorPath(level-vectorBits, node)
+	return ret
+}
+
+func (v *Vector) pushTail(level uint, parent, tailnode *vnode) *vnode {
+	subidx := ((v.cnt - 1) >> level) & vectorMask
+	ret := parent.clone()
+	if level == vectorBits {
+		ret.kids[subidx] = tailnode
+	} else if child := ret.kids[subidx]; child != nil {
+		ret.kids[subidx] = v.pushTail(level-vectorBits, child, tailnode)
+	} else {
+		ret.kids[subidx] = newVectorPath(level-vectorBits, tailnode)
+	}
+	return ret
+}
+
+// Append appends the given element to the end of the Vector, returning a copy
+// of the new Vector. Completes in O(log32(N)) time.
+func (v *Vector) Append(el interface{}) *Vector {
+	if v.Size() == 0 {
+		return &Vector{tail: []interface{}{el}, shift: vectorBits, cnt: 1}
+	}
+
+	// Room in the tail
+	if v.cnt-v.tailoff() < ARITY {
+		ntail := make([]interface{}, len(v.tail)+1)
+		copy(ntail, v.tail)
+		ntail[len(v.tail)] = el
+		return &Vector{v.root, ntail, v.shift, v.cnt + 1, v.off}
+	}
+
+	// Tail is full, push it into the trie
+	tailnode := &vnode{vals: v.tail}
+	nshift := v.shift
+	var nroot *vnode
+	if (v.cnt >> vectorBits) > (1 << v.shift) {
+		// Root overflow
+		nroot = &vnode{kids: make([]*vnode, ARITY)}
+		nroot.kids[0] = v.root
+		nroot.kids[1] = newVectorPath(v.shift, tailnode)
+		nshift += vectorBits
+	} else {
+		nroot = v.pushTail(v.shift, v.root, tailnode)
+	}
+	return &Vector{nroot, []interface{}{el}, nshift, v.cnt + 1, v.off}
+}
+
+func vectorAssoc(level uint, node *vnode, i uint64, el interface{}) *vnode {
+	ret := node.clone()
+	if level == 0 {
+		ret.vals[i&vectorMask] = el
+	} else {
+		subidx := (i >> level) & vectorMask
+		ret.kids[subidx] = vectorAssoc(level-vectorBits, node.kids[subidx], i, el)
+	}
+	return ret
+}
+
+// Assoc returns a copy of the Vector with the element at index i replaced by
+// the given one. If i is equal to the Vector's Size the element is appended
+// instead. The returned boolean is false, and the Vector unchanged, if i is
+// beyond that. Completes in O(log32(N)) time.
+func (v *Vector) Assoc(i uint64, el interface{}) (*Vector, bool) {
+	size := v.Size()
+	if i == size {
+		return v.Append(el), true
+	} else if i > size {
+		return v, false
+	}
+
+	i += v.off
+	if i >= v.tailoff() {
+		ntail := make([]interface{}, len(v.tail))
+		copy(ntail, v.tail)
+		ntail[i&vectorMask] = el
+		return &Vector{v.root, ntail, v.shift, v.cnt, v.off}, true
+	}
+	nroot := vectorAssoc(v.shift, v.root, i, el)
+	return &Vector{nroot, v.tail, v.shift, v.cnt, v.off}, true
+}
+
+// Returns a copy of the trie with only the first n leaves kept, or nil if n is
+// zero
+func vectorTrim(level uint, node *vnode, n uint64) *vnode {
+	if n == 0 {
+		return nil
+	}
+	last := (n - 1) << vectorBits
+	subidx := (last >> level) & vectorMask
+	ret := &vnode{kids: make([]*vnode, ARITY)}
+	copy(ret.kids[:subidx], node.kids[:subidx])
+	if level == vectorBits {
+		ret.kids[subidx] = node.kids[subidx]
+	} else {
+		ret.kids[subidx] = vectorTrim(level-vectorBits, node.kids[subidx], n)
+	}
+	return ret
+}
+
+// Returns a copy of the Vector with only the first cnt elements of the
+// underlying trie and tail kept (including those before off).
+func (v *Vector) truncate(cnt uint64) *Vector {
+	if cnt == v.cnt {
+		return v
+	} else if cnt <= v.off {
+		return nil
+	}
+
+	// The new tail is whatever leaf now holds the last element. Leaf slices
+	// are never written to once created, so it's safe to share it.
+	leaf := v.leafFor(cnt - 1)
+	tailLen := ((cnt - 1) & vectorMask) + 1
+	ntail := leaf[:tailLen:tailLen]
+
+	nroot := vectorTrim(v.shift, v.root, vectorTailoff(cnt)>>vectorBits)
+	nshift := v.shift
+	for nshift > vectorBits && (nroot == nil || nroot.kids[1] == nil) {
+		if nroot != nil {
+			nroot = nroot.kids[0]
+		}
+		nshift -= vectorBits
+	}
+	return &Vector{nroot, ntail, nshift, cnt, v.off}
+}
+
+// Pop returns the last element in the Vector, a copy of the Vector with that
+// element removed, and true. If the Vector is empty returns nil, the empty
+// Vector, and false. Completes in O(log32(N)) time.
+func (v *Vector) Pop() (interface{}, *Vector, bool) {
+	size := v.Size()
+	if size == 0 {
+		return nil, v, false
+	}
+	el, _ := v.Nth(size - 1)
+	return el, v.truncate(v.cnt - 1), true
+}
+
+// Slice returns a Vector of the elements starting at index from and ending
+// just before index to. The returned boolean is false, and the Vector
+// unchanged, if the indices are out of bounds or to is less than from. The
+// returned Vector shares its nodes with the original, so elements outside of
+// the slice are not released until both are. Completes in O(log32(N)) time.
+func (v *Vector) Slice(from, to uint64) (*Vector, bool) {
+	if to < from || to > v.Size() {
+		return v, false
+	} else if from == to {
+		return nil, true
+	}
+	nv := v.truncate(v.off + to)
+	if from > 0 {
+		nv = &Vector{nv.root, nv.tail, nv.shift, nv.cnt, nv.off + from}
+	}
+	return nv, true
+}
+
+// FirstRest is an implementation of FirstRest for Seq interface. The rest
+// Vector shares all of its nodes with the original. Completes in O(log32(N))
+// time.
+func (v *Vector) FirstRest() (interface{}, Seq, bool) {
+	el, ok := v.Nth(0)
+	if !ok {
+		return nil, v, false
+	} else if v.Size() == 1 {
+		return el, (*Vector)(nil), true
+	}
+	return el, &Vector{v.root, v.tail, v.shift, v.cnt, v.off + 1}, true
+}
+
+// Calls fn on each element of the Vector, in order, without allocating, until
+// fn returns false
+func (v *Vector) each(fn func(interface{}) bool) {
+	if v == nil {
+		return
+	}
+	for i := v.off; i < v.cnt; {
+		leaf := v.leafFor(i)
+		for j := i & vectorMask; j < uint64(len(leaf)) && i < v.cnt; i, j = i+1, j+1 {
+			if !fn(leaf[j]) {
+				return
+			}
+		}
+	}
+}
+
+// Hash implements the Hash method for the Setable interface
+func (v *Vector) Hash(i uint32) uint32 {
+	sum := uint32(0)
+	v.each(func(el interface{}) bool {
+		sum += hash(el, i)
+		return true
+	})
+	return sum
+}
+
+// Equal implements Equal for the Setable and Comparable interfaces
+func (v *Vector) Equal(v2 interface{}) bool {
+	vv, ok := v2.(*Vector)
+	if !ok || v.Size() != vv.Size() {
+		return false
+	}
+
+	i := uint64(0)
+	eq := true
+	v.each(func(el interface{}) bool {
+		el2, _ := vv.Nth(i)
+		i++
+		eq = equal(el, el2)
+		return eq
+	})
+	return eq
+}
+
+// String is an implementation of String for Stringer interface.
+func (v *Vector) String() string {
+	return ToString(v, "[", "]")
+}
+
+// ToVector returns the elements in the Seq as a Vector. Has similar properties
+// as ToSlice. In general this completes in O(N) time. If the given Seq is
+// already a Vector it will complete in O(1) time.
+func ToVector(s Seq) *Vector {
+	if v, ok := s.(*Vector); ok {
+		return v
+	}
+	return NewVector(ToSlice(s)...)
+}
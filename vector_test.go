@@ -0,0 +1,179 @@
+package seq
+
+import (
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns a slice of the integers [0, n)
+func intsTo(n int) []interface{} {
+	ints := make([]interface{}, n)
+	for i := range ints {
+		ints[i] = i
+	}
+	return ints
+}
+
+// Asserts that the given Vector is properly formed and has all of the given
+// elements, checking each by Nth as well as by iterating
+func assertVector(t *T, ints []interface{}, v *Vector) {
+	assert.Equal(t, uint64(len(ints)), v.Size())
+	assert.Equal(t, uint64(len(ints)), Size(v))
+	assert.Equal(t, ints, ToSlice(v))
+	for i := range ints {
+		el, ok := v.Nth(uint64(i))
+		assert.Equal(t, true, ok)
+		assert.Equal(t, ints[i], el)
+	}
+	_, ok := v.Nth(uint64(len(ints)))
+	assert.Equal(t, false, ok)
+}
+
+// Test creating a Vector and calling the Seq interface methods on it
+func TestVectorSeq(t *T) {
+	ints := []interface{}{1, "a", 5.0}
+
+	v := NewVector(ints...)
+	sv := testSeqGen(t, v, ints)
+
+	// sv should be empty at this point
+	v = ToVector(sv)
+	var nilpointer *Vector
+	assert.Equal(t, uint64(0), Size(v))
+	assert.Equal(t, nilpointer, v)
+	assert.Equal(t, nilpointer, NewVector())
+}
+
+// Test appending to a Vector, across the boundaries where the tail is pushed
+// into the trie and where the trie gains a level
+func TestVectorAppend(t *T) {
+	var v *Vector
+	var prev *Vector
+	for _, n := range []int{1, 31, 32, 33, 64, 65, 1024, 1056, 1057, 2000} {
+		for i := int(v.Size()); i < n; i++ {
+			prev = v
+			v = v.Append(i)
+		}
+		assertVector(t, intsTo(n), v)
+		assertVector(t, intsTo(n-1), prev)
+	}
+	assertVector(t, intsTo(33000), NewVector(intsTo(33000)...))
+}
+
+// Test replacing elements in a Vector
+func TestVectorAssoc(t *T) {
+	ints := intsTo(1100)
+	v := NewVector(ints...)
+
+	for _, i := range []int{0, 31, 32, 500, 1023, 1024, 1099} {
+		v2, ok := v.Assoc(uint64(i), "x")
+		assert.Equal(t, true, ok)
+		ints2 := append([]interface{}{}, ints...)
+		ints2[i] = "x"
+		assertVector(t, ints2, v2)
+	}
+	assertVector(t, ints, v)
+
+	// Assoc-ing at Size appends, beyond that fails
+	v2, ok := v.Assoc(1100, 1100)
+	assert.Equal(t, true, ok)
+	assertVector(t, intsTo(1101), v2)
+	v2, ok = v.Assoc(1101, 1101)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, v, v2)
+}
+
+// Test popping elements off the end of a Vector, all the way to empty
+func TestVectorPop(t *T) {
+	ints := intsTo(1100)
+	v := NewVector(ints...)
+	for i := len(ints) - 1; i >= 0; i-- {
+		el, nv, ok := v.Pop()
+		assert.Equal(t, true, ok)
+		assert.Equal(t, i, el)
+		if i%97 == 0 || i < 70 {
+			assertVector(t, ints[:i], nv)
+		}
+		v = nv
+	}
+	var nilpointer *Vector
+	assert.Equal(t, nilpointer, v)
+
+	el, v, ok := v.Pop()
+	assert.Equal(t, nil, el)
+	assert.Equal(t, nilpointer, v)
+	assert.Equal(t, false, ok)
+
+	// Popping after a pop should still be able to grow correctly
+	v = NewVector(intsTo(1025)...)
+	_, v, _ = v.Pop()
+	_, v, _ = v.Pop()
+	v = v.Append(1023).Append(1024).Append(1025)
+	assertVector(t, intsTo(1026), v)
+}
+
+// Test slicing a Vector, and that slices can be modified independently
+func TestVectorSlice(t *T) {
+	ints := intsTo(2000)
+	v := NewVector(ints...)
+
+	for _, r := range [][2]int{{0, 2000}, {0, 0}, {5, 5}, {0, 1}, {10, 40}, {31, 33}, {32, 1024}, {1000, 2000}, {1999, 2000}} {
+		sv, ok := v.Slice(uint64(r[0]), uint64(r[1]))
+		assert.Equal(t, true, ok)
+		assertVector(t, ints[r[0]:r[1]], sv)
+
+		// Appending onto a slice doesn't effect the original
+		sv = sv.Append("x")
+		exp := append(append([]interface{}{}, ints[r[0]:r[1]]...), "x")
+		assertVector(t, exp, sv)
+
+		// Nor does assoc-ing onto it
+		if len(exp) > 1 {
+			sv, _ = sv.Assoc(0, "y")
+			exp[0] = "y"
+			assertVector(t, exp, sv)
+		}
+	}
+	assertVector(t, ints, v)
+
+	// Slices of slices
+	sv, _ := v.Slice(100, 1500)
+	sv, _ = sv.Slice(50, 1000)
+	assertVector(t, ints[150:1100], sv)
+
+	_, ok := v.Slice(10, 5)
+	assert.Equal(t, false, ok)
+	_, ok = v.Slice(0, 2001)
+	assert.Equal(t, false, ok)
+}
+
+// Test that two Vectors compare equality correctly
+func TestVectorEqual(t *T) {
+	v1, v2 := NewVector(), NewVector()
+	assert.Equal(t, true, v1.Equal(v2))
+
+	v1 = v1.Append(1)
+	assert.Equal(t, false, v1.Equal(v2))
+	assert.Equal(t, false, v2.Equal(v1))
+
+	v2 = v2.Append(1)
+	assert.Equal(t, true, v1.Equal(v2))
+
+	// Vectors built different ways with the same contents
+	v1 = NewVector(intsTo(100)...)
+	v2 = NewVector(append([]interface{}{-1}, intsTo(100)...)...)
+	_, v2r, _ := v2.FirstRest()
+	assert.Equal(t, true, v1.Equal(v2r))
+	assert.Equal(t, false, v1.Equal(NewList(intsTo(100)...)))
+
+	// Vectors in Sets
+	s := NewSet(NewVector(1, 2))
+	_, ok := s.GetVal(NewVector(1, 2))
+	assert.Equal(t, true, ok)
+}
+
+// Test the string representation of a Vector
+func TestVectorString(t *T) {
+	assert.Equal(t, "[ 0 1 2 ]", NewVector(0, 1, 2).String())
+}